@@ -0,0 +1,308 @@
+package geometry
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+	"github.com/pkg/errors"
+)
+
+// AugmentConfig is created with Augment and once fully configured, can be executed
+// with Done.
+type AugmentConfig struct {
+	positions *tensors.Tensor
+	vectors   []*tensors.Tensor
+	rng       *rand.Rand
+
+	rotate             bool
+	reflectProbability float64
+	minScale, maxScale float64
+	jitterStddev       float64
+}
+
+// Augmented holds the results of the augmentation created by Augment.
+//
+// The transformation applied to the positions (each row of the positions tensor, as a column vector x) is
+// x' = Transform * x + noise, where Transform = Scale * Rotation * Reflection, and the noise is the
+// random jitter. The vectors are transformed the same way, but without the jitter.
+type Augmented struct {
+	// Positions after the augmentation, with the same shape and dtype as the original positions.
+	Positions *tensors.Tensor
+
+	// Vectors after the augmentation, one per vector tensor given to AugmentConfig.WithVectors.
+	Vectors []*tensors.Tensor
+
+	// Rotation matrix applied, shaped [dimension, dimension], with the same dtype as the positions.
+	// It is the identity if AugmentConfig.Rotate was not configured.
+	Rotation *tensors.Tensor
+
+	// ReflectedAxis is the axis that was reflected (negated), before the rotation. It is -1 if no reflection happened.
+	ReflectedAxis int
+
+	// Scale factor applied.
+	Scale float64
+
+	// Transform is the full linear transformation applied, shaped [dimension, dimension], with the same dtype as the
+	// positions: Scale * Rotation * Reflection.
+	Transform *tensors.Tensor
+}
+
+// Augment creates a random augmentation of the given positions, typically used for data augmentation
+// of point clouds and molecules, for models that are supposed to be invariant or equivariant to rotations,
+// reflections or scaling.
+//
+// This runs only on CPU -- no graphs or backends are used. See RandomRotation, ApplyTransform and RandomJitter for
+// the equivalent operations as graph functions.
+//
+// Args:
+//   - positions: shaped [numPoints, dimension] (dimension is usually 2 or 3), or more generally [..., dimension].
+//     Only float32 and float64 data types are supported.
+//   - rng: the random number generator used, which makes the augmentation reproducible if seeded. E.g.:
+//     rand.New(rand.NewPCG(seed, 0)).
+//
+// It returns a configuration that should be configured with the augmentations to apply (by default no change is
+// made): see AugmentConfig.Rotate, AugmentConfig.Reflect, AugmentConfig.Scale and AugmentConfig.Jitter.
+// Call AugmentConfig.Done to perform the operation.
+func Augment(positions *tensors.Tensor, rng *rand.Rand) *AugmentConfig {
+	return &AugmentConfig{
+		positions: positions,
+		rng:       rng,
+		minScale:  1,
+		maxScale:  1,
+	}
+}
+
+// WithVectors adds vector features (e.g. edge displacements, velocities, forces), shaped [..., dimension] with
+// the same dtype as the positions, that are transformed the same way as the positions, except for the jitter.
+func (c *AugmentConfig) WithVectors(vectors ...*tensors.Tensor) *AugmentConfig {
+	c.vectors = append(c.vectors, vectors...)
+	return c
+}
+
+// Rotate configures the augmentation to apply a uniformly random rotation (sampled from the Haar distribution
+// on SO(dimension)) around the origin.
+func (c *AugmentConfig) Rotate() *AugmentConfig {
+	c.rotate = true
+	return c
+}
+
+// Reflect configures the augmentation to, with the given probability, reflect (negate) a uniformly random axis,
+// before the rotation.
+func (c *AugmentConfig) Reflect(probability float64) *AugmentConfig {
+	c.reflectProbability = probability
+	return c
+}
+
+// Scale configures the augmentation to scale the positions (around the origin) by a factor uniformly sampled
+// from [minScale, maxScale].
+func (c *AugmentConfig) Scale(minScale, maxScale float64) *AugmentConfig {
+	c.minScale, c.maxScale = minScale, maxScale
+	return c
+}
+
+// Jitter configures the augmentation to add independent Gaussian noise with the given standard deviation to each
+// coordinate of the positions. The vectors are not affected.
+func (c *AugmentConfig) Jitter(stddev float64) *AugmentConfig {
+	c.jitterStddev = stddev
+	return c
+}
+
+// Done performs the augmentation as configured.
+func (c *AugmentConfig) Done() (*Augmented, error) {
+	positions := c.positions
+	if positions == nil || positions.Shape().Rank() < 1 {
+		return nil, errors.New("Augment positions must be shaped [..., dimension]")
+	}
+	if c.rng == nil {
+		return nil, errors.New("Augment requires a random number generator")
+	}
+	dtype := positions.DType()
+	if dtype != dtypes.Float32 && dtype != dtypes.Float64 {
+		return nil, errors.Errorf("DType of the positions (%s) must be either Float32 or Float64", positions.Shape())
+	}
+	dimension := positions.Shape().Dim(-1)
+	for i, vectors := range c.vectors {
+		if vectors.DType() != dtype || vectors.Shape().Rank() < 1 || vectors.Shape().Dim(-1) != dimension {
+			return nil, errors.Errorf("vectors #%d (%s) must be shaped [..., %d] with the same dtype as the positions (%s)",
+				i, vectors.Shape(), dimension, positions.Shape())
+		}
+	}
+	if c.minScale <= 0 || c.maxScale < c.minScale {
+		return nil, errors.Errorf("invalid scale range [%g, %g]", c.minScale, c.maxScale)
+	}
+	if c.jitterStddev < 0 {
+		return nil, errors.Errorf("invalid negative jitter standard deviation %g", c.jitterStddev)
+	}
+
+	result := &Augmented{ReflectedAxis: -1}
+	rotation := identityMatrix(dimension)
+	if c.rotate {
+		rotation = randomRotationMatrix(c.rng, dimension)
+	}
+	transform := make([]float64, dimension*dimension)
+	copy(transform, rotation)
+	if c.reflectProbability > 0 && c.rng.Float64() < c.reflectProbability {
+		// Negating an axis before the rotation is the same as negating the corresponding column of the rotation.
+		result.ReflectedAxis = c.rng.IntN(dimension)
+		for row := range dimension {
+			transform[row*dimension+result.ReflectedAxis] = -transform[row*dimension+result.ReflectedAxis]
+		}
+	}
+	result.Scale = c.minScale + c.rng.Float64()*(c.maxScale-c.minScale)
+	for i := range transform {
+		transform[i] *= result.Scale
+	}
+
+	var err error
+	if result.Rotation, err = float64ToTensor(rotation, dtype, dimension, dimension); err != nil {
+		return nil, err
+	}
+	if result.Transform, err = float64ToTensor(transform, dtype, dimension, dimension); err != nil {
+		return nil, err
+	}
+	result.Positions = applyTransform(positions, transform, dimension, c.rng, c.jitterStddev)
+	result.Vectors = make([]*tensors.Tensor, len(c.vectors))
+	for i, vectors := range c.vectors {
+		result.Vectors[i] = applyTransform(vectors, transform, dimension, nil, 0)
+	}
+	return result, nil
+}
+
+// applyTransform returns a new tensor with the points (shaped [..., dimension]) transformed by the flat
+// [dimension, dimension] transform matrix, plus optional Gaussian jitter.
+func applyTransform(points *tensors.Tensor, transform []float64, dimension int, rng *rand.Rand, jitterStddev float64) *tensors.Tensor {
+	output := tensors.FromShape(shapes.Make(points.DType(), points.Shape().Dimensions...))
+	switch points.DType() {
+	case dtypes.Float32:
+		tensors.ConstFlatData[float32](points, func(flatPoints []float32) {
+			tensors.MutableFlatData[float32](output, func(flatOutput []float32) {
+				applyTransformImpl(flatPoints, flatOutput, transform, dimension, rng, jitterStddev)
+			})
+		})
+	case dtypes.Float64:
+		tensors.ConstFlatData[float64](points, func(flatPoints []float64) {
+			tensors.MutableFlatData[float64](output, func(flatOutput []float64) {
+				applyTransformImpl(flatPoints, flatOutput, transform, dimension, rng, jitterStddev)
+			})
+		})
+	}
+	return output
+}
+
+func applyTransformImpl[T KDTreePointType](points, output []T, transform []float64, dimension int, rng *rand.Rand, jitterStddev float64) {
+	numPoints := len(points) / dimension
+	for pointIdx := range numPoints {
+		point := points[pointIdx*dimension : (pointIdx+1)*dimension]
+		for row := range dimension {
+			var sum float64
+			for col, value := range point {
+				sum += transform[row*dimension+col] * float64(value)
+			}
+			if jitterStddev > 0 {
+				sum += rng.NormFloat64() * jitterStddev
+			}
+			output[pointIdx*dimension+row] = T(sum)
+		}
+	}
+}
+
+// identityMatrix returns a flat identity matrix shaped [dimension, dimension].
+func identityMatrix(dimension int) []float64 {
+	matrix := make([]float64, dimension*dimension)
+	for i := range dimension {
+		matrix[i*dimension+i] = 1
+	}
+	return matrix
+}
+
+// randomRotationMatrix returns a flat rotation matrix shaped [dimension, dimension], uniformly sampled
+// (from the Haar distribution) from SO(dimension).
+//
+// It orthonormalizes (with Gram-Schmidt) a matrix of independent normal values, which yields a uniformly
+// random orthogonal matrix, and then negates one column if needed to make the determinant +1.
+func randomRotationMatrix(rng *rand.Rand, dimension int) []float64 {
+	for {
+		// columns[col][row], to make Gram-Schmidt simpler.
+		columns := make([][]float64, dimension)
+		degenerate := false
+		for col := range dimension {
+			column := make([]float64, dimension)
+			for row := range column {
+				column[row] = rng.NormFloat64()
+			}
+			for _, previous := range columns[:col] {
+				var dot float64
+				for row := range dimension {
+					dot += column[row] * previous[row]
+				}
+				for row := range dimension {
+					column[row] -= dot * previous[row]
+				}
+			}
+			var norm float64
+			for _, v := range column {
+				norm += v * v
+			}
+			norm = math.Sqrt(norm)
+			if norm < 1e-9 {
+				// Extremely unlikely: sample again.
+				degenerate = true
+				break
+			}
+			for row := range column {
+				column[row] /= norm
+			}
+			columns[col] = column
+		}
+		if degenerate {
+			continue
+		}
+		matrix := make([]float64, dimension*dimension)
+		for col, column := range columns {
+			for row, v := range column {
+				matrix[row*dimension+col] = v
+			}
+		}
+		if matrixDeterminant(matrix, dimension) < 0 {
+			for row := range dimension {
+				matrix[row*dimension] = -matrix[row*dimension]
+			}
+		}
+		return matrix
+	}
+}
+
+// matrixDeterminant of the flat matrix shaped [dimension, dimension], using Gaussian elimination with partial pivoting.
+func matrixDeterminant(matrix []float64, dimension int) float64 {
+	m := make([]float64, len(matrix))
+	copy(m, matrix)
+	det := 1.0
+	for col := range dimension {
+		pivot := col
+		for row := col + 1; row < dimension; row++ {
+			if math.Abs(m[row*dimension+col]) > math.Abs(m[pivot*dimension+col]) {
+				pivot = row
+			}
+		}
+		if m[pivot*dimension+col] == 0 {
+			return 0
+		}
+		if pivot != col {
+			for k := range dimension {
+				m[col*dimension+k], m[pivot*dimension+k] = m[pivot*dimension+k], m[col*dimension+k]
+			}
+			det = -det
+		}
+		det *= m[col*dimension+col]
+		for row := col + 1; row < dimension; row++ {
+			factor := m[row*dimension+col] / m[col*dimension+col]
+			for k := col; k < dimension; k++ {
+				m[row*dimension+k] -= factor * m[col*dimension+k]
+			}
+		}
+	}
+	return det
+}
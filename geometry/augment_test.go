@@ -0,0 +1,154 @@
+package geometry
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	_ "github.com/gomlx/gomlx/backends/default"
+	. "github.com/gomlx/gomlx/graph"
+	"github.com/gomlx/gomlx/graph/graphtest"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAugment(t *testing.T) {
+	positions := tensors.FromValue([][]float64{{1, 0, 0}, {0, 2, 0}, {1, 2, 3}})
+	vectors := tensors.FromValue([][]float64{{0, 0, 1}})
+	for _, reflect := range []float64{0, 1} {
+		result, err := Augment(positions, rand.New(rand.NewPCG(42, 0))).
+			WithVectors(vectors).
+			Rotate().
+			Reflect(reflect).
+			Scale(0.5, 2).
+			Done()
+		require.NoError(t, err)
+
+		// Rotation is orthonormal with determinant 1.
+		rotation := tensors.CopyFlatData[float64](result.Rotation)
+		require.InDelta(t, 1.0, matrixDeterminant(rotation, 3), 1e-9)
+		for i := range 3 {
+			for j := range 3 {
+				var dot float64
+				for k := range 3 {
+					dot += rotation[i*3+k] * rotation[j*3+k]
+				}
+				require.InDelta(t, map[bool]float64{true: 1, false: 0}[i == j], dot, 1e-9)
+			}
+		}
+
+		// Transform includes the scale and the reflection.
+		transform := tensors.CopyFlatData[float64](result.Transform)
+		require.True(t, result.Scale >= 0.5 && result.Scale <= 2)
+		wantDeterminant := result.Scale * result.Scale * result.Scale
+		if reflect == 1 {
+			require.NotEqual(t, -1, result.ReflectedAxis)
+			wantDeterminant = -wantDeterminant
+		} else {
+			require.Equal(t, -1, result.ReflectedAxis)
+		}
+		require.InDelta(t, wantDeterminant, matrixDeterminant(transform, 3), 1e-9)
+
+		// Positions and vectors are transformed (no jitter configured).
+		original := positions.Value().([][]float64)
+		for pointIdx, point := range result.Positions.Value().([][]float64) {
+			for row := range 3 {
+				var want float64
+				for col := range 3 {
+					want += transform[row*3+col] * original[pointIdx][col]
+				}
+				require.InDelta(t, want, point[row], 1e-9)
+			}
+		}
+		require.InDeltaSlice(t, []float64{transform[2], transform[5], transform[8]},
+			result.Vectors[0].Value().([][]float64)[0], 1e-9)
+	}
+
+	// Reproducible by seed, and jitter only affects positions.
+	augment := func() *Augmented {
+		result, err := Augment(positions, rand.New(rand.NewPCG(7, 0))).WithVectors(vectors).Jitter(0.1).Done()
+		require.NoError(t, err)
+		return result
+	}
+	result1, result2 := augment(), augment()
+	require.Equal(t, result1.Positions.Value(), result2.Positions.Value())
+	require.NotEqual(t, positions.Value(), result1.Positions.Value())
+	require.Equal(t, vectors.Value(), result1.Vectors[0].Value())
+
+	// Errors.
+	_, err := Augment(positions, rand.New(rand.NewPCG(7, 0))).WithVectors(tensors.FromValue([][]float64{{0, 1}})).Done()
+	require.Error(t, err)
+	_, err = Augment(positions, rand.New(rand.NewPCG(7, 0))).Scale(0, 1).Done()
+	require.Error(t, err)
+}
+
+func TestRandomRotation(t *testing.T) {
+	for _, dimension := range []int{2, 3} {
+		graphtest.RunTestGraphFn(t, "RandomRotation", func(g *Graph) (inputs, outputs []*Node) {
+			rngState := Const(g, RngStateFromSeed(42))
+			_, rotation := RandomRotation(rngState, dtypes.Float64, dimension)
+			inputs = []*Node{rngState}
+			// Rotation times its transpose must be the identity.
+			outputs = []*Node{Dot(rotation, Transpose(rotation, 0, 1))}
+			return
+		}, []any{
+			tensors.FromFlatDataAndDimensions(identityMatrix(dimension), dimension, dimension).Value(),
+		}, 1e-6)
+	}
+	graphtest.RunTestGraphFn(t, "ApplyTransform", func(g *Graph) (inputs, outputs []*Node) {
+		points := Const(g, [][][]float32{{{1, 2}, {3, 4}}})
+		transform := Const(g, [][]float32{{0, -1}, {1, 0}})
+		inputs = []*Node{points, transform}
+		outputs = []*Node{ApplyTransform(points, transform)}
+		return
+	}, []any{
+		[][][]float32{{{-2, 1}, {-4, 3}}},
+	}, 1e-6)
+}
+
+func TestRandomReflectionAndScaling(t *testing.T) {
+	for _, dimension := range []int{2, 3} {
+		for _, probability := range []float64{0, 1} {
+			// Reflection is orthonormal, and its trace counts the non-reflected axes minus the reflected one.
+			wantTrace := float64(dimension)
+			if probability == 1 {
+				wantTrace -= 2
+			}
+			graphtest.RunTestGraphFn(t, "RandomReflection", func(g *Graph) (inputs, outputs []*Node) {
+				rngState := Const(g, RngStateFromSeed(42))
+				_, reflection := RandomReflection(rngState, dtypes.Float64, dimension, probability)
+				inputs = []*Node{rngState}
+				outputs = []*Node{
+					Dot(reflection, Transpose(reflection, 0, 1)),
+					ReduceAllSum(Where(Diagonal(g, dimension), reflection, ZerosLike(reflection))),
+				}
+				return
+			}, []any{
+				tensors.FromFlatDataAndDimensions(identityMatrix(dimension), dimension, dimension).Value(),
+				wantTrace,
+			}, 1e-6)
+		}
+
+		// Scaling is diagonal, with the same factor in [minScale, maxScale] for all axes.
+		graphtest.RunTestGraphFn(t, "RandomScaling", func(g *Graph) (inputs, outputs []*Node) {
+			rngState := Const(g, RngStateFromSeed(42))
+			_, scaling := RandomScaling(rngState, dtypes.Float64, dimension, 0.5, 2)
+			inputs = []*Node{rngState}
+			scale := Slice(scaling, AxisElem(0), AxisElem(0))
+			outputs = []*Node{
+				Sub(scaling, DiagonalWithValue(Reshape(scale), dimension)),
+				LogicalAnd(GreaterOrEqual(scale, Const(g, 0.5)), LessOrEqual(scale, Const(g, 2.0))),
+			}
+			return
+		}, []any{
+			tensors.FromFlatDataAndDimensions(make([]float64, dimension*dimension), dimension, dimension).Value(),
+			[][]bool{{true}},
+		}, 1e-6)
+	}
+	require.Panics(t, func() {
+		ExecOnce(graphtest.BuildTestBackend(), func(rngState *Node) *Node {
+			_, scaling := RandomScaling(rngState, dtypes.Float64, 3, 0, 1)
+			return scaling
+		}, RngStateFromSeed(42))
+	})
+}
@@ -0,0 +1,130 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/gomlx/exceptions"
+	. "github.com/gomlx/gomlx/graph"
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// RandomRotation returns a uniformly random rotation matrix (sampled from the Haar distribution on SO(dimension)),
+// shaped [dimension, dimension], as a graph function. It is the graph equivalent of AugmentConfig.Rotate.
+//
+// Only dimensions 2 and 3 are supported. For 3D it normalizes a quaternion with independent normal values, which
+// is uniformly distributed in the unit sphere of quaternions.
+//
+// It uses and updates the random number generator (RNG) state in rngState, see graph.RngStateFromSeed.
+// The returned rotation can be used with ApplyTransform, and also in equivariance tests.
+func RandomRotation(rngState *Node, dtype dtypes.DType, dimension int) (newRngState, rotation *Node) {
+	if !dtype.IsFloat() {
+		exceptions.Panicf("RandomRotation: invalid dtype %s, it must be float", dtype)
+	}
+	g := rngState.Graph()
+	switch dimension {
+	case 2:
+		var angle *Node
+		newRngState, angle = RandomUniform(rngState, shapes.Make(dtype))
+		angle = MulScalar(angle, 2*math.Pi)
+		cos, sin := Cos(angle), Sin(angle)
+		rotation = Stack([]*Node{
+			Stack([]*Node{cos, Neg(sin)}, 0),
+			Stack([]*Node{sin, cos}, 0),
+		}, 0)
+	case 3:
+		var quaternion *Node
+		newRngState, quaternion = RandomNormal(rngState, shapes.Make(dtype, 4))
+		quaternion = L2Normalize(quaternion, 0)
+		w := Squeeze(SliceAxis(quaternion, 0, AxisElem(0)), 0)
+		x := Squeeze(SliceAxis(quaternion, 0, AxisElem(1)), 0)
+		y := Squeeze(SliceAxis(quaternion, 0, AxisElem(2)), 0)
+		z := Squeeze(SliceAxis(quaternion, 0, AxisElem(3)), 0)
+		one := ScalarOne(g, dtype)
+		two := func(a, b *Node) *Node { return MulScalar(Mul(a, b), 2) }
+		rotation = Stack([]*Node{
+			Stack([]*Node{Sub(one, Add(two(y, y), two(z, z))), Sub(two(x, y), two(z, w)), Add(two(x, z), two(y, w))}, 0),
+			Stack([]*Node{Add(two(x, y), two(z, w)), Sub(one, Add(two(x, x), two(z, z))), Sub(two(y, z), two(x, w))}, 0),
+			Stack([]*Node{Sub(two(x, z), two(y, w)), Add(two(y, z), two(x, w)), Sub(one, Add(two(x, x), two(y, y)))}, 0),
+		}, 0)
+	default:
+		exceptions.Panicf("RandomRotation: only dimensions 2 and 3 are supported, got %d", dimension)
+	}
+	return
+}
+
+// ApplyTransform applies the linear transform (e.g. a rotation matrix) shaped [dimension, dimension] to the
+// points (or vectors) shaped [..., dimension]: each point p (as a column vector) becomes transform * p.
+//
+// It returns a tensor with the same shape and dtype as points.
+func ApplyTransform(points, transform *Node) *Node {
+	if points.Rank() < 1 {
+		exceptions.Panicf("ApplyTransform: points must be shaped [..., dimension], got %s", points.Shape())
+	}
+	dimension := points.Shape().Dim(-1)
+	if transform.Shape().CheckDims(dimension, dimension) != nil {
+		exceptions.Panicf("ApplyTransform: transform must be shaped [%d, %d], got %s", dimension, dimension, transform.Shape())
+	}
+	dims := points.Shape().Dimensions
+	flat := Reshape(points, -1, dimension)
+	transformed := Dot(flat, Transpose(transform, 0, 1))
+	return Reshape(transformed, dims...)
+}
+
+// RandomJitter adds independent Gaussian noise with the given standard deviation to each coordinate of the points.
+// It is the graph equivalent of AugmentConfig.Jitter.
+//
+// It uses and updates the random number generator (RNG) state in rngState, see graph.RngStateFromSeed.
+func RandomJitter(rngState, points *Node, stddev float64) (newRngState, jittered *Node) {
+	var noise *Node
+	newRngState, noise = RandomNormal(rngState, points.Shape())
+	jittered = Add(points, MulScalar(noise, stddev))
+	return
+}
+
+// RandomReflection returns a reflection matrix shaped [dimension, dimension] that, with the given probability,
+// negates a uniformly random axis, and otherwise is the identity. It is the graph equivalent of
+// AugmentConfig.Reflect.
+//
+// It uses and updates the random number generator (RNG) state in rngState, see graph.RngStateFromSeed.
+// The returned reflection can be used with ApplyTransform, and combined with the other transforms as
+// Scale * Rotation * Reflection, the order used by Augment.
+func RandomReflection(rngState *Node, dtype dtypes.DType, dimension int, probability float64) (newRngState, reflection *Node) {
+	if !dtype.IsFloat() {
+		exceptions.Panicf("RandomReflection: invalid dtype %s, it must be float", dtype)
+	}
+	if dimension <= 0 {
+		exceptions.Panicf("RandomReflection: invalid dimension %d", dimension)
+	}
+	g := rngState.Graph()
+	var draw, axis *Node
+	newRngState, draw = RandomUniform(rngState, shapes.Make(dtype))
+	newRngState, axis = RandomIntN(newRngState, dimension, shapes.Make(dtypes.Int32))
+	reflect := LogicalAnd(
+		LessThan(draw, Scalar(g, dtype, probability)),
+		Equal(Iota(g, shapes.Make(dtypes.Int32, dimension), 0), axis))
+	signs := Where(reflect, Scalar(g, dtype, -1), Scalar(g, dtype, 1))
+	reflection = Mul(DiagonalWithValue(ScalarOne(g, dtype), dimension), ExpandAxes(signs, 0))
+	return
+}
+
+// RandomScaling returns a scaling matrix shaped [dimension, dimension], with a factor uniformly sampled from
+// [minScale, maxScale] in the diagonal. It is the graph equivalent of AugmentConfig.Scale.
+//
+// It panics if minScale <= 0 or maxScale < minScale.
+//
+// It uses and updates the random number generator (RNG) state in rngState, see graph.RngStateFromSeed.
+// The returned scaling can be used with ApplyTransform.
+func RandomScaling(rngState *Node, dtype dtypes.DType, dimension int, minScale, maxScale float64) (newRngState, scaling *Node) {
+	if !dtype.IsFloat() {
+		exceptions.Panicf("RandomScaling: invalid dtype %s, it must be float", dtype)
+	}
+	if minScale <= 0 || maxScale < minScale {
+		exceptions.Panicf("RandomScaling: invalid scale range [%g, %g]", minScale, maxScale)
+	}
+	var scale *Node
+	newRngState, scale = RandomUniform(rngState, shapes.Make(dtype))
+	scale = AddScalar(MulScalar(scale, maxScale-minScale), minScale)
+	scaling = DiagonalWithValue(scale, dimension)
+	return
+}
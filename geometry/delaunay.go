@@ -0,0 +1,497 @@
+package geometry
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+	"github.com/pkg/errors"
+)
+
+// DelaunayConfig is created with DelaunayEdges and once fully configured, can be executed
+// with Done.
+type DelaunayConfig struct {
+	points *tensors.Tensor
+}
+
+// DelaunayEdges returns the edges of the Delaunay triangulation (2D) or tetrahedralization (3D) of the given points.
+//
+// This runs only on CPU -- no graphs or backends are used. It uses the Bowyer-Watson algorithm, inserting the points
+// in a spatially sorted order and locating each one by walking the triangulation, so for well distributed points
+// it takes close to O(numPoints * log(numPoints)). Highly degenerate inputs can still take O(numPoints^2).
+//
+// Args:
+//   - points: shaped [numPoints, dimension], where the dimension must be 2 or 3.
+//     Only float32 and float64 data types are supported.
+//
+// It returns a configuration that can be optionally configured. Call DelaunayConfig.Done to perform
+// the operation.
+//
+// Duplicate points are not connected to anything, and points in degenerate positions (e.g. all collinear in 2D)
+// won't generate any simplices.
+func DelaunayEdges(points *tensors.Tensor) *DelaunayConfig {
+	return &DelaunayConfig{
+		points: points,
+	}
+}
+
+// Done performs the DelaunayEdges operation as configured.
+//
+// It returns:
+//   - edges: shaped [2, numEdges]Int32, with each undirected edge listed only once, with edges[0][i] < edges[1][i],
+//     and sorted by source and then target.
+//   - simplices: shaped [numSimplices, dimension+1]Int32 with the indices of the points of each triangle (2D)
+//     or tetrahedron (3D). The vertices of each simplex are sorted, and the simplices are sorted lexicographically.
+//
+// If no simplices could be created (e.g.: fewer than dimension+1 points), it returns an error.
+func (c *DelaunayConfig) Done() (edges, simplices *tensors.Tensor, err error) {
+	points := c.points
+	if points == nil || points.Size() == 0 {
+		return nil, nil, errors.New("DelaunayEdges points are empty")
+	}
+	if points.Shape().Rank() != 2 {
+		return nil, nil, errors.Errorf("points (%s) must be rank 2: [numPoints, dimension]", points.Shape())
+	}
+	dimension := points.Shape().Dimensions[1]
+	if dimension != 2 && dimension != 3 {
+		return nil, nil, errors.Errorf("DelaunayEdges only supports dimension 2 or 3, got points shaped %s", points.Shape())
+	}
+
+	var flatPoints []float64
+	switch points.DType() {
+	case dtypes.Float32:
+		tensors.ConstFlatData[float32](points, func(flat []float32) {
+			flatPoints = make([]float64, len(flat))
+			for i, v := range flat {
+				flatPoints[i] = float64(v)
+			}
+		})
+	case dtypes.Float64:
+		tensors.ConstFlatData[float64](points, func(flat []float64) {
+			flatPoints = slices.Clone(flat)
+		})
+	default:
+		return nil, nil, errors.Errorf("DType of the points (%s) must be either Float32 or Float64", points.Shape())
+	}
+
+	flatSimplices := delaunayImpl(flatPoints, dimension)
+	simplexSize := dimension + 1
+	numSimplices := len(flatSimplices) / simplexSize
+	if numSimplices == 0 {
+		return nil, nil, errors.Errorf("no simplices found for points shaped %s, are they in a degenerate position?", points.Shape())
+	}
+
+	// Collect unique edges from simplices.
+	type edge struct{ source, target int32 }
+	edgesList := make([]edge, 0, numSimplices*simplexSize*(simplexSize-1)/2)
+	for simplexIdx := range numSimplices {
+		simplex := flatSimplices[simplexIdx*simplexSize : (simplexIdx+1)*simplexSize]
+		for i := range simplexSize {
+			for j := i + 1; j < simplexSize; j++ {
+				a, b := simplex[i], simplex[j]
+				if a > b {
+					a, b = b, a
+				}
+				edgesList = append(edgesList, edge{a, b})
+			}
+		}
+	}
+	slices.SortFunc(edgesList, func(e1, e2 edge) int {
+		if e1.source != e2.source {
+			return cmp.Compare(e1.source, e2.source)
+		}
+		return cmp.Compare(e1.target, e2.target)
+	})
+	edgesList = slices.Compact(edgesList)
+	numEdges := len(edgesList)
+
+	edges = tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	tensors.MutableFlatData[int32](edges, func(flatEdges []int32) {
+		for i, e := range edgesList {
+			flatEdges[i] = e.source
+			flatEdges[numEdges+i] = e.target
+		}
+	})
+	simplices = tensors.FromFlatDataAndDimensions(flatSimplices, numSimplices, simplexSize)
+	return edges, simplices, nil
+}
+
+// delaunaySimplex is a triangle (2D) or tetrahedron (3D) during the construction of the triangulation.
+type delaunaySimplex struct {
+	vertices [4]int32 // Only the first dimension+1 are used.
+
+	// neighbors[i] is the index of the simplex across the facet opposite to vertices[i], or -1 if there is none.
+	neighbors [4]int32
+	center    [3]float64
+	radius2   float64
+	alive     bool
+
+	// visited is set to pointIdx+1 when the simplex is added to the cavity of the point pointIdx.
+	visited int32
+}
+
+// delaunayBoundaryFacet is a facet (an edge in 2D or a triangle in 3D) of the boundary of the cavity of the point
+// being inserted, with the simplex outside the cavity that shares it (or -1).
+type delaunayBoundaryFacet struct {
+	vertices [3]int32 // Only the first dimension are used.
+	outside  int32
+}
+
+// delaunayImpl returns the flat simplices (numSimplices * (dimension+1) indices) of the Delaunay triangulation
+// using the Bowyer-Watson algorithm.
+//
+// The points are normalized to the unit hypercube before the triangulation, to improve numeric stability, and
+// inserted in Morton (Z-curve) order. Each point is located by walking from the last created simplex, and its cavity
+// (the simplices whose circumsphere contains it) is grown by a breadth-first search over the neighbor simplices.
+func delaunayImpl(points []float64, dimension int) []int32 {
+	numPoints := len(points) / dimension
+	simplexSize := dimension + 1
+
+	// Normalize points to [0, 1]^dimension.
+	minCoords, maxCoords := calculateBoundingBox(points, dimension)
+	var scale float64
+	for axis := range dimension {
+		scale = max(scale, maxCoords[axis]-minCoords[axis])
+	}
+	if scale == 0 {
+		// All points are the same.
+		return nil
+	}
+	normalized := make([]float64, len(points), len(points)+simplexSize*dimension)
+	for i, v := range points {
+		normalized[i] = (v - minCoords[i%dimension]) / scale
+	}
+	order := delaunayInsertionOrder(normalized, dimension)
+
+	// Append the vertices of a super-simplex that contains the whole unit hypercube with a large margin:
+	// the larger the margin, the fewer convex-hull edges are lost due to the super-simplex vertices.
+	const margin = 1000.0
+	sideLength := 3 * float64(dimension) * (1 + margin)
+	for vertex := range simplexSize {
+		for axis := range dimension {
+			coord := -margin
+			if vertex == axis+1 {
+				coord += sideLength
+			}
+			normalized = append(normalized, coord)
+		}
+	}
+	superVertex := int32(numPoints)
+
+	superSimplex := delaunaySimplex{neighbors: [4]int32{-1, -1, -1, -1}}
+	for vertex := range simplexSize {
+		superSimplex.vertices[vertex] = superVertex + int32(vertex)
+	}
+	delaunaySetCircumsphere(&superSimplex, normalized, dimension)
+	simplices := []delaunaySimplex{superSimplex}
+
+	var freeIndices, cavity []int32
+	var boundary []delaunayBoundaryFacet
+	ridges := make(map[[2]int32][2]int32) // Ridge -> (new simplex, index of the vertex opposite to its facet).
+	var last int32
+	for _, pointIdx := range order {
+		point := normalized[int(pointIdx)*dimension : int(pointIdx+1)*dimension]
+		start := delaunayLocate(simplices, normalized, dimension, last, point)
+		if start < 0 {
+			// Duplicate point: it lies exactly on the vertex of existing simplices.
+			continue
+		}
+
+		// Grow the cavity from the starting simplex, and collect its boundary: the facets shared with simplices
+		// outside the cavity.
+		stamp := pointIdx + 1
+		simplices[start].visited = stamp
+		cavity = append(cavity[:0], start)
+		boundary = boundary[:0]
+		for cavityIdx := 0; cavityIdx < len(cavity); cavityIdx++ {
+			simplex := &simplices[cavity[cavityIdx]]
+			for skip := range simplexSize {
+				neighbor := simplex.neighbors[skip]
+				if neighbor >= 0 {
+					if simplices[neighbor].visited == stamp {
+						continue
+					}
+					if delaunayInCircumsphere(&simplices[neighbor], point, dimension) {
+						simplices[neighbor].visited = stamp
+						cavity = append(cavity, neighbor)
+						continue
+					}
+				}
+				facet := delaunayBoundaryFacet{vertices: [3]int32{-1, -1, -1}, outside: neighbor}
+				var facetIdx int
+				for vertex := range simplexSize {
+					if vertex != skip {
+						facet.vertices[facetIdx] = simplex.vertices[vertex]
+						facetIdx++
+					}
+				}
+				boundary = append(boundary, facet)
+			}
+		}
+		for _, simplexIdx := range cavity {
+			simplices[simplexIdx].alive = false
+			freeIndices = append(freeIndices, simplexIdx)
+		}
+
+		// Create new simplices connecting the boundary facets to the new point, reusing the slots of the cavity.
+		clear(ridges)
+		for _, facet := range boundary {
+			var simplexIdx int32
+			if numFree := len(freeIndices); numFree > 0 {
+				simplexIdx = freeIndices[numFree-1]
+				freeIndices = freeIndices[:numFree-1]
+			} else {
+				simplexIdx = int32(len(simplices))
+				simplices = append(simplices, delaunaySimplex{})
+			}
+			simplex := delaunaySimplex{neighbors: [4]int32{-1, -1, -1, -1}}
+			copy(simplex.vertices[:dimension], facet.vertices[:dimension])
+			simplex.vertices[dimension] = pointIdx
+			simplex.neighbors[dimension] = facet.outside
+			delaunaySetCircumsphere(&simplex, normalized, dimension)
+			simplices[simplexIdx] = simplex
+
+			// Point the outside simplex back to the new one, across the shared facet.
+			if facet.outside >= 0 {
+				outside := &simplices[facet.outside]
+				for vertex := range simplexSize {
+					if !slices.Contains(facet.vertices[:dimension], outside.vertices[vertex]) {
+						outside.neighbors[vertex] = simplexIdx
+						break
+					}
+				}
+			}
+
+			// New simplices sharing a ridge (the facet minus one vertex) are neighbors across the facet that
+			// contains the ridge and the new point.
+			for skip := range dimension {
+				ridge := [2]int32{-1, -1}
+				var ridgeIdx int
+				for vertex := range dimension {
+					if vertex != skip {
+						ridge[ridgeIdx] = facet.vertices[vertex]
+						ridgeIdx++
+					}
+				}
+				if ridge[1] >= 0 && ridge[0] > ridge[1] {
+					ridge[0], ridge[1] = ridge[1], ridge[0]
+				}
+				if other, found := ridges[ridge]; found {
+					simplices[simplexIdx].neighbors[skip] = other[0]
+					simplices[other[0]].neighbors[other[1]] = simplexIdx
+					delete(ridges, ridge)
+				} else {
+					ridges[ridge] = [2]int32{simplexIdx, int32(skip)}
+				}
+			}
+			last = simplexIdx
+		}
+	}
+
+	// Collect simplices that don't touch the super-simplex, with their vertices sorted.
+	var result [][4]int32
+	for _, simplex := range simplices {
+		if !simplex.alive {
+			continue
+		}
+		if slices.ContainsFunc(simplex.vertices[:simplexSize], func(v int32) bool { return v >= superVertex }) {
+			continue
+		}
+		if math.IsInf(simplex.radius2, 1) {
+			// Degenerate (flat) simplex.
+			continue
+		}
+		slices.Sort(simplex.vertices[:simplexSize])
+		result = append(result, simplex.vertices)
+	}
+	slices.SortFunc(result, func(a, b [4]int32) int { return slices.Compare(a[:], b[:]) })
+	flatSimplices := make([]int32, 0, len(result)*simplexSize)
+	for _, vertices := range result {
+		flatSimplices = append(flatSimplices, vertices[:simplexSize]...)
+	}
+	return flatSimplices
+}
+
+// delaunayInsertionOrder returns the indices of the normalized points (in [0, 1]^dimension) sorted by their Morton
+// (Z-curve) code, so consecutive points are close to each other, and walking from the last created simplex is
+// short. Duplicate points keep their relative order.
+func delaunayInsertionOrder(normalized []float64, dimension int) []int32 {
+	const bitsPerAxis = 16
+	numPoints := len(normalized) / dimension
+	codes := make([]uint64, numPoints)
+	for pointIdx := range numPoints {
+		var code uint64
+		for axis := range dimension {
+			quantized := uint64(normalized[pointIdx*dimension+axis] * ((1 << bitsPerAxis) - 1))
+			for bit := range bitsPerAxis {
+				code |= ((quantized >> bit) & 1) << (bit*dimension + axis)
+			}
+		}
+		codes[pointIdx] = code
+	}
+	order := make([]int32, numPoints)
+	for pointIdx := range order {
+		order[pointIdx] = int32(pointIdx)
+	}
+	slices.SortStableFunc(order, func(a, b int32) int { return cmp.Compare(codes[a], codes[b]) })
+	return order
+}
+
+// delaunayLocate returns the index of a simplex whose circumsphere contains the point, to start its cavity.
+//
+// It walks from the simplex start towards the point, crossing the facets that separate the current simplex from
+// the point, until it reaches the simplex that contains it. If the walk fails (degenerate simplices or numeric
+// issues), it falls back to scanning all simplices. It returns -1 if the point is a duplicate of an existing vertex.
+func delaunayLocate(simplices []delaunaySimplex, points []float64, dimension int, start int32, point []float64) int32 {
+	simplexSize := dimension + 1
+	current := start
+	var facet [3]int32
+walk:
+	for step := range len(simplices) {
+		simplex := &simplices[current]
+		if math.IsInf(simplex.radius2, 1) {
+			break
+		}
+		crossed := false
+		for offset := range simplexSize {
+			// Rotate the first facet tested at each step, to avoid cycling.
+			skip := (offset + step) % simplexSize
+			var facetIdx int
+			for vertex := range simplexSize {
+				if vertex != skip {
+					facet[facetIdx] = simplex.vertices[vertex]
+					facetIdx++
+				}
+			}
+			opposite := simplex.vertices[skip]
+			side := delaunayOrientation(points, dimension, facet[:dimension], point)
+			oppositeSide := delaunayOrientation(points, dimension, facet[:dimension],
+				points[int(opposite)*dimension:int(opposite+1)*dimension])
+			if side*oppositeSide < 0 {
+				if simplex.neighbors[skip] < 0 {
+					break walk
+				}
+				current = simplex.neighbors[skip]
+				crossed = true
+				break
+			}
+		}
+		if crossed {
+			continue
+		}
+		if delaunayInCircumsphere(simplex, point, dimension) {
+			return current
+		}
+		for _, vertex := range simplex.vertices[:simplexSize] {
+			if slices.Equal(point, points[int(vertex)*dimension:int(vertex+1)*dimension]) {
+				return -1
+			}
+		}
+		break
+	}
+
+	// Fallback: any simplex whose circumsphere contains the point, preferring non-degenerate ones.
+	found := int32(-1)
+	for simplexIdx := range simplices {
+		simplex := &simplices[simplexIdx]
+		if !simplex.alive || !delaunayInCircumsphere(simplex, point, dimension) {
+			continue
+		}
+		if !math.IsInf(simplex.radius2, 1) {
+			return int32(simplexIdx)
+		}
+		if found < 0 {
+			found = int32(simplexIdx)
+		}
+	}
+	return found
+}
+
+// delaunayInCircumsphere returns whether the point is strictly inside the circumsphere of the simplex.
+// Degenerate (flat) simplices contain every point.
+func delaunayInCircumsphere(simplex *delaunaySimplex, point []float64, dimension int) bool {
+	var dist2 float64
+	for axis := range dimension {
+		diff := point[axis] - simplex.center[axis]
+		dist2 += diff * diff
+	}
+	return dist2 < simplex.radius2*(1-1e-12)
+}
+
+// delaunayOrientation returns the signed volume spanned by the facet (dimension vertices) and the point x: its sign
+// tells on which side of the facet the point lies.
+func delaunayOrientation(points []float64, dimension int, facet []int32, x []float64) float64 {
+	p0 := points[int(facet[0])*dimension : int(facet[0]+1)*dimension]
+	p1 := points[int(facet[1])*dimension : int(facet[1]+1)*dimension]
+	if dimension == 2 {
+		return (p1[0]-p0[0])*(x[1]-p0[1]) - (p1[1]-p0[1])*(x[0]-p0[0])
+	}
+	p2 := points[int(facet[2])*dimension : int(facet[2]+1)*dimension]
+	var a, b, c [3]float64
+	for axis := range 3 {
+		a[axis], b[axis], c[axis] = p1[axis]-p0[axis], p2[axis]-p0[axis], x[axis]-p0[axis]
+	}
+	return a[0]*(b[1]*c[2]-b[2]*c[1]) - a[1]*(b[0]*c[2]-b[2]*c[0]) + a[2]*(b[0]*c[1]-b[1]*c[0])
+}
+
+// delaunaySetCircumsphere calculates the center and squared radius of the circumsphere of the simplex.
+//
+// The center c is the solution to the linear system 2*(p_i - p_0) . c = |p_i|^2 - |p_0|^2, for i in [1, dimension].
+// If the simplex is degenerate (flat), radius2 is set to +Inf, so it will be removed at the next insertion.
+func delaunaySetCircumsphere(simplex *delaunaySimplex, points []float64, dimension int) {
+	simplex.alive = true
+	p0 := points[int(simplex.vertices[0])*dimension : int(simplex.vertices[0]+1)*dimension]
+
+	// Build the augmented matrix [A | b], using coordinates relative to p0 for numeric stability:
+	// 2*(p_i - p_0) . (c - p_0) = |p_i - p_0|^2.
+	var system [3][4]float64
+	for row := range dimension {
+		vertex := simplex.vertices[row+1]
+		pI := points[int(vertex)*dimension : int(vertex+1)*dimension]
+		var norm2 float64
+		for axis := range dimension {
+			diff := pI[axis] - p0[axis]
+			system[row][axis] = 2 * diff
+			norm2 += diff * diff
+		}
+		system[row][dimension] = norm2
+	}
+
+	// Gaussian elimination with partial pivoting.
+	for col := range dimension {
+		pivot := col
+		for row := col + 1; row < dimension; row++ {
+			if math.Abs(system[row][col]) > math.Abs(system[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(system[pivot][col]) < 1e-14 {
+			simplex.radius2 = math.Inf(1)
+			return
+		}
+		system[col], system[pivot] = system[pivot], system[col]
+		for row := col + 1; row < dimension; row++ {
+			factor := system[row][col] / system[col][col]
+			for k := col; k <= dimension; k++ {
+				system[row][k] -= factor * system[col][k]
+			}
+		}
+	}
+	var relCenter [3]float64
+	for row := dimension - 1; row >= 0; row-- {
+		sum := system[row][dimension]
+		for k := row + 1; k < dimension; k++ {
+			sum -= system[row][k] * relCenter[k]
+		}
+		relCenter[row] = sum / system[row][row]
+	}
+
+	simplex.radius2 = 0
+	for axis := range dimension {
+		simplex.center[axis] = relCenter[axis] + p0[axis]
+		simplex.radius2 += relCenter[axis] * relCenter[axis]
+	}
+}
@@ -0,0 +1,110 @@
+package geometry
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelaunayEdges(t *testing.T) {
+	t.Run("square", func(t *testing.T) {
+		// A square with its center point: 4 triangles, all sharing the center.
+		pointsT := tensors.FromValue([][]float32{
+			{0, 0},
+			{1, 0},
+			{1, 1},
+			{0, 1},
+			{0.5, 0.5}})
+		edgesT, simplicesT, err := DelaunayEdges(pointsT).Done()
+		require.NoError(t, err)
+		require.Equal(t, [][]int32{
+			{0, 0, 0, 1, 1, 2, 2, 3},
+			{1, 3, 4, 2, 4, 3, 4, 4}}, edgesT.Value())
+		require.Equal(t, [][]int32{{0, 1, 4}, {0, 3, 4}, {1, 2, 4}, {2, 3, 4}}, simplicesT.Value())
+	})
+
+	for _, dimension := range []int{2, 3} {
+		t.Run(map[int]string{2: "random-2D", 3: "random-3D"}[dimension], func(t *testing.T) {
+			const numPoints = 200
+			pointsT := tensors.FromShape(shapes.Make(dtypes.Float64, numPoints, dimension))
+			tensors.MutableFlatData(pointsT, func(flat []float64) {
+				rng := rand.New(rand.NewPCG(0, 42))
+				for i := range flat {
+					flat[i] = 10*rng.Float64() - 5
+				}
+			})
+			edgesT, simplicesT, err := DelaunayEdges(pointsT).Done()
+			require.NoError(t, err)
+			require.Equal(t, 2, edgesT.Shape().Dimensions[0])
+			require.Equal(t, dimension+1, simplicesT.Shape().Dimensions[1])
+			points := pointsT.Value().([][]float64)
+
+			// Check the empty circumsphere property: no point is strictly inside the circumsphere of any simplex.
+			seenPoints := make([]bool, numPoints)
+			for _, simplex := range simplicesT.Value().([][]int32) {
+				flatPoints := make([]float64, 0, (dimension+1)*dimension)
+				for i, vertex := range simplex {
+					flatPoints = append(flatPoints, points[vertex]...)
+					simplex[i] = int32(i)
+					seenPoints[vertex] = true
+				}
+				s := delaunaySimplex{}
+				copy(s.vertices[:], simplex)
+				delaunaySetCircumsphere(&s, flatPoints, dimension)
+				for pointIdx, point := range points {
+					require.GreaterOrEqual(t, l2Dist2(point, s.center[:dimension]), s.radius2*(1-1e-6),
+						"point #%d is inside the circumsphere of simplex %v", pointIdx, simplex)
+				}
+			}
+			for pointIdx, seen := range seenPoints {
+				require.True(t, seen, "point #%d is not part of any simplex", pointIdx)
+			}
+
+			// Edges are unique and sorted, with source < target.
+			edges := edgesT.Value().([][]int32)
+			for i := range edges[0] {
+				require.Less(t, edges[0][i], edges[1][i])
+				if i > 0 {
+					require.True(t, edges[0][i-1] < edges[0][i] ||
+						(edges[0][i-1] == edges[0][i] && edges[1][i-1] < edges[1][i]))
+				}
+			}
+		})
+	}
+
+	t.Run("grid-3D", func(t *testing.T) {
+		// Grid points are degenerate (cospherical): the triangulation isn't unique, but its tetrahedra must fill
+		// the cube exactly, without overlaps.
+		const side = 6
+		var flat []float64
+		for x := range side {
+			for y := range side {
+				for z := range side {
+					flat = append(flat, float64(x), float64(y), float64(z))
+				}
+			}
+		}
+		_, simplicesT, err := DelaunayEdges(tensors.FromFlatDataAndDimensions(flat, side*side*side, 3)).Done()
+		require.NoError(t, err)
+		var volume float64
+		for _, simplex := range simplicesT.Value().([][]int32) {
+			apex := flat[simplex[3]*3 : simplex[3]*3+3]
+			volume += math.Abs(delaunayOrientation(flat, 3, simplex[:3], apex)) / 6
+		}
+		require.InDelta(t, float64((side-1)*(side-1)*(side-1)), volume, 1e-9)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, _, err := DelaunayEdges(tensors.FromValue([][]float32{{0}, {1}})).Done()
+		require.Error(t, err)
+		_, _, err = DelaunayEdges(tensors.FromValue([][]float32{{0, 0}, {1, 1}, {2, 2}})).Done()
+		require.Error(t, err)
+		_, _, err = DelaunayEdges(tensors.FromValue([][]int32{{0, 0}, {1, 0}, {0, 1}})).Done()
+		require.Error(t, err)
+	})
+}
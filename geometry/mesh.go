@@ -0,0 +1,191 @@
+package geometry
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomlx/gnn/graph"
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+	"github.com/pkg/errors"
+)
+
+// Mesh is a 3D triangle mesh: a set of vertices and the triangular faces connecting them.
+//
+// Meshes can be read from OBJ, OFF and PLY files, see LoadMesh, ReadOBJ, ReadOFF and ReadPLY.
+// Polygonal faces with more than 3 vertices are triangulated (as a fan) when read.
+type Mesh struct {
+	// Vertices has size NumVertices * 3, the underlying shape being [NumVertices, 3], stored in row-major order.
+	Vertices []float64
+
+	// NumVertices in the mesh.
+	NumVertices int
+
+	// Faces has size NumFaces * 3, the underlying shape being [NumFaces, 3], stored in row-major order.
+	// Each face holds the indices of its 3 vertices.
+	Faces []int32
+
+	// NumFaces in the mesh.
+	NumFaces int
+}
+
+// LoadMesh reads a mesh from the given file, choosing the format by the file extension:
+// ".obj", ".off" or ".ply" (case-insensitive).
+func LoadMesh(filePath string) (*Mesh, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open mesh file %q", filePath)
+	}
+	defer func() { _ = f.Close() }()
+
+	var mesh *Mesh
+	ext := strings.ToLower(filepath.Ext(filePath))
+	switch ext {
+	case ".obj":
+		mesh, err = ReadOBJ(f)
+	case ".off":
+		mesh, err = ReadOFF(f)
+	case ".ply":
+		mesh, err = ReadPLY(f)
+	default:
+		return nil, errors.Errorf("unknown mesh file extension %q for %q, only .obj, .off and .ply are supported", ext, filePath)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to read mesh from %q", filePath)
+	}
+	return mesh, nil
+}
+
+// Validate checks that the sizes of Vertices and Faces match NumVertices and NumFaces,
+// and that all face indices are valid vertex indices.
+func (m *Mesh) Validate() error {
+	if len(m.Vertices) != m.NumVertices*3 {
+		return errors.Errorf("mesh has %d vertices, but len(Vertices)=%d, wanted %d", m.NumVertices, len(m.Vertices), m.NumVertices*3)
+	}
+	if len(m.Faces) != m.NumFaces*3 {
+		return errors.Errorf("mesh has %d faces, but len(Faces)=%d, wanted %d", m.NumFaces, len(m.Faces), m.NumFaces*3)
+	}
+	for i, vertexIdx := range m.Faces {
+		if vertexIdx < 0 || int(vertexIdx) >= m.NumVertices {
+			return errors.Errorf("face #%d refers to vertex %d, but there are only %d vertices", i/3, vertexIdx, m.NumVertices)
+		}
+	}
+	return nil
+}
+
+// Positions returns the vertices positions as a tensor shaped [NumVertices, 3], with the given dtype,
+// which must be Float32 or Float64.
+func (m *Mesh) Positions(dtype dtypes.DType) (*tensors.Tensor, error) {
+	return float64ToTensor(m.Vertices, dtype, m.NumVertices, 3)
+}
+
+// FacesTensor returns the faces as a tensor shaped [NumFaces, 3]Int32.
+func (m *Mesh) FacesTensor() *tensors.Tensor {
+	return tensors.FromFlatDataAndDimensions(m.Faces, m.NumFaces, 3)
+}
+
+// FaceToEdges converts the faces of the mesh to edges, returned as a tensor shaped [2, numEdges]Int32,
+// where edge_i connects vertex edges[0][i] to vertex edges[1][i].
+//
+// Each side of each triangle generates edges in both directions, and duplicates (from sides shared by
+// adjacent faces) are removed, as in graph.UnionEdges. The edges are sorted by source and then target.
+func (m *Mesh) FaceToEdges() (*tensors.Tensor, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	if m.NumFaces == 0 {
+		return nil, errors.New("mesh has no faces, so no edges can be created")
+	}
+	numEdges := m.NumFaces * 6
+	edgesT := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	tensors.MutableFlatData[int32](edgesT, func(flatEdges []int32) {
+		edgeIdx := 0
+		for faceIdx := range m.NumFaces {
+			face := m.Faces[faceIdx*3 : (faceIdx+1)*3]
+			for i := range 3 {
+				a, b := face[i], face[(i+1)%3]
+				flatEdges[edgeIdx], flatEdges[numEdges+edgeIdx] = a, b
+				flatEdges[edgeIdx+1], flatEdges[numEdges+edgeIdx+1] = b, a
+				edgeIdx += 2
+			}
+		}
+	})
+	// UnionEdges removes the duplicates, and returns the edges sorted by source and then target.
+	return graph.UnionEdges(edgesT)
+}
+
+// FaceNormals returns the unit normal vector of each face, shaped [NumFaces, 3], with the given dtype,
+// which must be Float32 or Float64.
+//
+// The direction of the normal follows the right-hand rule on the order of the face vertices.
+// Degenerate faces (with zero area) have a zero normal.
+func (m *Mesh) FaceNormals(dtype dtypes.DType) (*tensors.Tensor, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	normals := make([]float64, m.NumFaces*3)
+	for faceIdx := range m.NumFaces {
+		cross := m.faceCross(faceIdx)
+		norm := math.Sqrt(cross[0]*cross[0] + cross[1]*cross[1] + cross[2]*cross[2])
+		if norm == 0 {
+			continue
+		}
+		for axis := range 3 {
+			normals[faceIdx*3+axis] = cross[axis] / norm
+		}
+	}
+	return float64ToTensor(normals, dtype, m.NumFaces, 3)
+}
+
+// FaceAreas returns the area of each face, shaped [NumFaces], with the given dtype,
+// which must be Float32 or Float64.
+func (m *Mesh) FaceAreas(dtype dtypes.DType) (*tensors.Tensor, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	areas := make([]float64, m.NumFaces)
+	for faceIdx := range m.NumFaces {
+		cross := m.faceCross(faceIdx)
+		areas[faceIdx] = math.Sqrt(cross[0]*cross[0]+cross[1]*cross[1]+cross[2]*cross[2]) / 2
+	}
+	return float64ToTensor(areas, dtype, m.NumFaces)
+}
+
+// faceCross returns the cross-product (v1-v0) x (v2-v0) for the given face.
+func (m *Mesh) faceCross(faceIdx int) [3]float64 {
+	face := m.Faces[faceIdx*3 : (faceIdx+1)*3]
+	v0 := m.Vertices[face[0]*3 : face[0]*3+3]
+	v1 := m.Vertices[face[1]*3 : face[1]*3+3]
+	v2 := m.Vertices[face[2]*3 : face[2]*3+3]
+	var a, b [3]float64
+	for axis := range 3 {
+		a[axis] = v1[axis] - v0[axis]
+		b[axis] = v2[axis] - v0[axis]
+	}
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+// float64ToTensor converts the flat values to a tensor with the given dtype (Float32 or Float64) and dimensions.
+func float64ToTensor(values []float64, dtype dtypes.DType, dimensions ...int) (*tensors.Tensor, error) {
+	switch dtype {
+	case dtypes.Float64:
+		return tensors.FromFlatDataAndDimensions(values, dimensions...), nil
+	case dtypes.Float32:
+		t := tensors.FromShape(shapes.Make(dtypes.Float32, dimensions...))
+		tensors.MutableFlatData[float32](t, func(flat []float32) {
+			for i, v := range values {
+				flat[i] = float32(v)
+			}
+		})
+		return t, nil
+	default:
+		return nil, errors.Errorf("unsupported dtype %s, only Float32 and Float64 are supported", dtype)
+	}
+}
@@ -0,0 +1,174 @@
+package geometry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomlx/gopjrt/dtypes"
+	"github.com/stretchr/testify/require"
+)
+
+// unitSquareVertices and unitSquareFaces are the expected contents of the test meshes below: a unit square
+// on the z=0 plane, split into 2 triangles.
+var (
+	unitSquareVertices = []float64{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0}
+	unitSquareFaces    = []int32{0, 1, 2, 0, 2, 3}
+)
+
+func requireUnitSquare(t *testing.T, mesh *Mesh) {
+	require.NoError(t, mesh.Validate())
+	require.Equal(t, 4, mesh.NumVertices)
+	require.Equal(t, 2, mesh.NumFaces)
+	require.Equal(t, unitSquareVertices, mesh.Vertices)
+	require.Equal(t, unitSquareFaces, mesh.Faces)
+}
+
+func TestReadOBJ(t *testing.T) {
+	mesh, err := ReadOBJ(strings.NewReader(`# Unit square
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vn 0 0 1
+f 1//1 2//1 3//1
+f -4/1/1 -2/1/1 -1/1/1
+`))
+	require.NoError(t, err)
+	requireUnitSquare(t, mesh)
+
+	// Quad is triangulated.
+	mesh, err = ReadOBJ(strings.NewReader("v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nf 1 2 3 4\n"))
+	require.NoError(t, err)
+	requireUnitSquare(t, mesh)
+
+	_, err = ReadOBJ(strings.NewReader("v 0 0 0\nv 1 0 0\nf 1 2 3\n"))
+	require.Error(t, err)
+}
+
+func TestReadOFF(t *testing.T) {
+	mesh, err := ReadOFF(strings.NewReader(`OFF
+# Unit square
+4 2 0
+0 0 0
+1 0 0
+1 1 0
+0 1 0
+3 0 1 2
+3 0 2 3 255 0 0
+`))
+	require.NoError(t, err)
+	requireUnitSquare(t, mesh)
+
+	mesh, err = ReadOFF(strings.NewReader("OFF 4 1 0\n0 0 0\n1 0 0\n1 1 0\n0 1 0\n4 0 1 2 3\n"))
+	require.NoError(t, err)
+	requireUnitSquare(t, mesh)
+
+	_, err = ReadOFF(strings.NewReader("OFF 4 1 0\n0 0 0\n1 0 0\n"))
+	require.Error(t, err)
+}
+
+func TestReadPLY(t *testing.T) {
+	t.Run("ascii", func(t *testing.T) {
+		mesh, err := ReadPLY(strings.NewReader(`ply
+format ascii 1.0
+comment Unit square
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+element face 1
+property list uchar int vertex_indices
+element edge 1
+property int vertex1
+property int vertex2
+end_header
+0 0 0 255
+1 0 0 255
+1 1 0 255
+0 1 0 255
+4 0 1 2 3
+0 1
+`))
+		require.NoError(t, err)
+		requireUnitSquare(t, mesh)
+	})
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			format := map[binary.ByteOrder]string{
+				binary.LittleEndian: "binary_little_endian",
+				binary.BigEndian:    "binary_big_endian",
+			}[order]
+			buf.WriteString("ply\nformat " + format + " 1.0\n" +
+				"element vertex 4\nproperty double x\nproperty double y\nproperty double z\nproperty short flag\n" +
+				"element face 2\nproperty list uchar uint vertex_index\nend_header\n")
+			for i := range 4 {
+				require.NoError(t, binary.Write(&buf, order, unitSquareVertices[i*3:(i+1)*3]))
+				require.NoError(t, binary.Write(&buf, order, int16(-1)))
+			}
+			for i := range 2 {
+				buf.WriteByte(3)
+				for _, vertexIdx := range unitSquareFaces[i*3 : (i+1)*3] {
+					require.NoError(t, binary.Write(&buf, order, uint32(vertexIdx)))
+				}
+			}
+			mesh, err := ReadPLY(&buf)
+			require.NoError(t, err)
+			requireUnitSquare(t, mesh)
+		})
+	}
+
+	_, err := ReadPLY(strings.NewReader("ply\nformat ascii 1.0\nelement vertex 2\nproperty float x\nend_header\n0\n"))
+	require.Error(t, err)
+}
+
+func TestLoadMesh(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "square.OBJ")
+	require.NoError(t, os.WriteFile(filePath, []byte("v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nf 1 2 3 4\n"), 0o644))
+	mesh, err := LoadMesh(filePath)
+	require.NoError(t, err)
+	requireUnitSquare(t, mesh)
+
+	_, err = LoadMesh(filepath.Join(t.TempDir(), "square.stl"))
+	require.Error(t, err)
+}
+
+func TestMeshFeatures(t *testing.T) {
+	mesh := &Mesh{
+		Vertices:    unitSquareVertices,
+		NumVertices: 4,
+		Faces:       unitSquareFaces,
+		NumFaces:    2,
+	}
+
+	edges, err := mesh.FaceToEdges()
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{
+		{0, 0, 0, 1, 1, 2, 2, 2, 3, 3},
+		{1, 2, 3, 0, 2, 0, 1, 3, 0, 2}}, edges.Value())
+
+	positions, err := mesh.Positions(dtypes.Float32)
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}, positions.Value())
+
+	normals, err := mesh.FaceNormals(dtypes.Float64)
+	require.NoError(t, err)
+	require.Equal(t, [][]float64{{0, 0, 1}, {0, 0, 1}}, normals.Value())
+
+	areas, err := mesh.FaceAreas(dtypes.Float32)
+	require.NoError(t, err)
+	require.Equal(t, []float32{0.5, 0.5}, areas.Value())
+
+	_, err = mesh.FaceAreas(dtypes.Int32)
+	require.Error(t, err)
+
+	invalid := &Mesh{Vertices: unitSquareVertices, NumVertices: 4, Faces: []int32{0, 1, 4}, NumFaces: 1}
+	_, err = invalid.FaceToEdges()
+	require.Error(t, err)
+}
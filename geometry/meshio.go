@@ -0,0 +1,406 @@
+package geometry
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// meshBuilder accumulates vertices and polygonal faces, triangulating the faces as a fan.
+type meshBuilder struct {
+	mesh Mesh
+}
+
+func (b *meshBuilder) addVertex(x, y, z float64) {
+	b.mesh.Vertices = append(b.mesh.Vertices, x, y, z)
+	b.mesh.NumVertices++
+}
+
+// addPolygon adds a polygon with the given vertex indices, triangulated as a fan around its first vertex.
+func (b *meshBuilder) addPolygon(polygon []int32) error {
+	if len(polygon) < 3 {
+		return errors.Errorf("face with only %d vertices, at least 3 are required", len(polygon))
+	}
+	for _, vertexIdx := range polygon {
+		if vertexIdx < 0 || int(vertexIdx) >= b.mesh.NumVertices {
+			return errors.Errorf("face refers to vertex %d, but there are only %d vertices", vertexIdx, b.mesh.NumVertices)
+		}
+	}
+	for i := 1; i < len(polygon)-1; i++ {
+		b.mesh.Faces = append(b.mesh.Faces, polygon[0], polygon[i], polygon[i+1])
+		b.mesh.NumFaces++
+	}
+	return nil
+}
+
+// ReadOBJ reads a mesh in the Wavefront OBJ format.
+//
+// Only vertex positions ("v") and faces ("f") are read, everything else (texture coordinates, normals,
+// groups, materials, etc.) is ignored. Negative (relative) vertex indices are supported.
+func ReadOBJ(r io.Reader) (*Mesh, error) {
+	var b meshBuilder
+	scanner := bufio.NewScanner(r)
+	var polygon []int32
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return nil, errors.Errorf("OBJ line %d: vertex with fewer than 3 coordinates", lineNum)
+			}
+			coords, err := parseFloats(fields[1:4])
+			if err != nil {
+				return nil, errors.WithMessagef(err, "OBJ line %d", lineNum)
+			}
+			b.addVertex(coords[0], coords[1], coords[2])
+		case "f":
+			polygon = polygon[:0]
+			for _, field := range fields[1:] {
+				// Faces can be given as "v", "v/vt", "v/vt/vn" or "v//vn": we only want the "v" part.
+				vertexStr, _, _ := strings.Cut(field, "/")
+				vertexIdx, err := strconv.Atoi(vertexStr)
+				if err != nil {
+					return nil, errors.Wrapf(err, "OBJ line %d: invalid face vertex %q", lineNum, field)
+				}
+				if vertexIdx < 0 {
+					// Relative to the end of the current list of vertices.
+					vertexIdx += b.mesh.NumVertices
+				} else {
+					// OBJ indices are 1-based.
+					vertexIdx--
+				}
+				polygon = append(polygon, int32(vertexIdx))
+			}
+			if err := b.addPolygon(polygon); err != nil {
+				return nil, errors.WithMessagef(err, "OBJ line %d", lineNum)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read OBJ")
+	}
+	return &b.mesh, nil
+}
+
+// ReadOFF reads a mesh in the Object File Format (OFF).
+//
+// Variations of the header (e.g. "COFF", "NOFF") are accepted, but any extra per-vertex
+// or per-face values (colors, normals) are ignored.
+func ReadOFF(r io.Reader) (*Mesh, error) {
+	var b meshBuilder
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	// nextFields returns the fields of the next non-empty line, with comments removed.
+	nextFields := func() ([]string, error) {
+		for scanner.Scan() {
+			lineNum++
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			if fields := strings.Fields(line); len(fields) > 0 {
+				return fields, nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to read OFF")
+		}
+		return nil, errors.New("unexpected end of OFF file")
+	}
+
+	fields, err := nextFields()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(fields[0], "OFF") {
+		return nil, errors.Errorf("OFF line %d: invalid header %q", lineNum, fields[0])
+	}
+	// The counts may be in the same line as the header.
+	fields = fields[1:]
+	if len(fields) == 0 {
+		if fields, err = nextFields(); err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) < 2 {
+		return nil, errors.Errorf("OFF line %d: expected number of vertices and faces", lineNum)
+	}
+	numVertices, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, errors.Wrapf(err, "OFF line %d: invalid number of vertices", lineNum)
+	}
+	numFaces, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, errors.Wrapf(err, "OFF line %d: invalid number of faces", lineNum)
+	}
+
+	for range numVertices {
+		if fields, err = nextFields(); err != nil {
+			return nil, err
+		}
+		if len(fields) < 3 {
+			return nil, errors.Errorf("OFF line %d: vertex with fewer than 3 coordinates", lineNum)
+		}
+		coords, err := parseFloats(fields[:3])
+		if err != nil {
+			return nil, errors.WithMessagef(err, "OFF line %d", lineNum)
+		}
+		b.addVertex(coords[0], coords[1], coords[2])
+	}
+
+	var polygon []int32
+	for range numFaces {
+		if fields, err = nextFields(); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(fields[0])
+		if err != nil || size < 0 || len(fields) < size+1 {
+			return nil, errors.Errorf("OFF line %d: invalid face", lineNum)
+		}
+		polygon = polygon[:0]
+		for _, field := range fields[1 : size+1] {
+			vertexIdx, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, errors.Wrapf(err, "OFF line %d: invalid face vertex %q", lineNum, field)
+			}
+			polygon = append(polygon, int32(vertexIdx))
+		}
+		if err := b.addPolygon(polygon); err != nil {
+			return nil, errors.WithMessagef(err, "OFF line %d", lineNum)
+		}
+	}
+	return &b.mesh, nil
+}
+
+// plyProperty describes a property of an element in a PLY file.
+type plyProperty struct {
+	name string
+	// valueType of the property, or of the items of the list, if isList is true.
+	valueType string
+	isList    bool
+	countType string
+}
+
+// plyElement describes an element (e.g. "vertex" or "face") in a PLY file.
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// plyTypeSizes maps the PLY types to their size in bytes.
+var plyTypeSizes = map[string]int{
+	"char": 1, "int8": 1, "uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2, "ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4, "uint": 4, "uint32": 4,
+	"float": 4, "float32": 4, "double": 8, "float64": 8,
+}
+
+// ReadPLY reads a mesh in the Polygon File Format (PLY), in either ASCII or binary (little or big-endian) formats.
+//
+// Only the "x", "y" and "z" properties of the "vertex" element, and the "vertex_indices" (or "vertex_index")
+// property of the "face" element are read. Any other elements and properties are ignored.
+func ReadPLY(r io.Reader) (*Mesh, error) {
+	reader := bufio.NewReader(r)
+	readLine := func() (string, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read PLY header")
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	// Parse header.
+	line, err := readLine()
+	if err != nil {
+		return nil, err
+	}
+	if line != "ply" {
+		return nil, errors.Errorf("invalid PLY magic number %q", line)
+	}
+	var format string
+	var elements []*plyElement
+	for {
+		if line, err = readLine(); err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "end_header" {
+			break
+		}
+		switch fields[0] {
+		case "format":
+			if len(fields) < 2 {
+				return nil, errors.Errorf("invalid PLY format line %q", line)
+			}
+			format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				return nil, errors.Errorf("invalid PLY element line %q", line)
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid PLY element line %q", line)
+			}
+			elements = append(elements, &plyElement{name: fields[1], count: count})
+		case "property":
+			if len(elements) == 0 {
+				return nil, errors.Errorf("PLY property %q defined before any element", line)
+			}
+			var property plyProperty
+			if len(fields) == 5 && fields[1] == "list" {
+				property = plyProperty{name: fields[4], valueType: fields[3], isList: true, countType: fields[2]}
+			} else if len(fields) == 3 {
+				property = plyProperty{name: fields[2], valueType: fields[1]}
+			} else {
+				return nil, errors.Errorf("invalid PLY property line %q", line)
+			}
+			if _, found := plyTypeSizes[property.valueType]; !found {
+				return nil, errors.Errorf("unknown PLY type in property line %q", line)
+			}
+			if _, found := plyTypeSizes[property.countType]; property.isList && !found {
+				return nil, errors.Errorf("unknown PLY type in property line %q", line)
+			}
+			lastElement := elements[len(elements)-1]
+			lastElement.properties = append(lastElement.properties, property)
+		}
+	}
+
+	var values plyValueReader
+	switch format {
+	case "ascii":
+		scanner := bufio.NewScanner(reader)
+		scanner.Split(bufio.ScanWords)
+		values = &plyASCIIReader{scanner: scanner}
+	case "binary_little_endian":
+		values = &plyBinaryReader{reader: reader, order: binary.LittleEndian}
+	case "binary_big_endian":
+		values = &plyBinaryReader{reader: reader, order: binary.BigEndian}
+	default:
+		return nil, errors.Errorf("unknown PLY format %q", format)
+	}
+
+	// Read elements.
+	var b meshBuilder
+	var polygon []int32
+	for _, element := range elements {
+		for elementIdx := range element.count {
+			var coords [3]float64
+			for _, property := range element.properties {
+				if property.isList {
+					count, err := values.read(property.countType)
+					if err != nil {
+						return nil, errors.WithMessagef(err, "reading PLY %s #%d", element.name, elementIdx)
+					}
+					polygon = polygon[:0]
+					for range int(count) {
+						value, err := values.read(property.valueType)
+						if err != nil {
+							return nil, errors.WithMessagef(err, "reading PLY %s #%d", element.name, elementIdx)
+						}
+						polygon = append(polygon, int32(value))
+					}
+					if element.name == "face" && (property.name == "vertex_indices" || property.name == "vertex_index") {
+						if err := b.addPolygon(polygon); err != nil {
+							return nil, errors.WithMessagef(err, "PLY face #%d", elementIdx)
+						}
+					}
+					continue
+				}
+				value, err := values.read(property.valueType)
+				if err != nil {
+					return nil, errors.WithMessagef(err, "reading PLY %s #%d", element.name, elementIdx)
+				}
+				switch property.name {
+				case "x":
+					coords[0] = value
+				case "y":
+					coords[1] = value
+				case "z":
+					coords[2] = value
+				}
+			}
+			if element.name == "vertex" {
+				b.addVertex(coords[0], coords[1], coords[2])
+			}
+		}
+	}
+	return &b.mesh, nil
+}
+
+// plyValueReader reads the values of the PLY body, one at a time.
+type plyValueReader interface {
+	read(valueType string) (float64, error)
+}
+
+type plyASCIIReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *plyASCIIReader) read(_ string) (float64, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return 0, errors.Wrap(err, "failed to read PLY")
+		}
+		return 0, errors.New("unexpected end of PLY file")
+	}
+	value, err := strconv.ParseFloat(r.scanner.Text(), 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid PLY value %q", r.scanner.Text())
+	}
+	return value, nil
+}
+
+type plyBinaryReader struct {
+	reader *bufio.Reader
+	order  binary.ByteOrder
+	buf    [8]byte
+}
+
+func (r *plyBinaryReader) read(valueType string) (float64, error) {
+	buf := r.buf[:plyTypeSizes[valueType]]
+	if _, err := io.ReadFull(r.reader, buf); err != nil {
+		return 0, errors.Wrap(err, "failed to read PLY")
+	}
+	switch valueType {
+	case "char", "int8":
+		return float64(int8(buf[0])), nil
+	case "uchar", "uint8":
+		return float64(buf[0]), nil
+	case "short", "int16":
+		return float64(int16(r.order.Uint16(buf))), nil
+	case "ushort", "uint16":
+		return float64(r.order.Uint16(buf)), nil
+	case "int", "int32":
+		return float64(int32(r.order.Uint32(buf))), nil
+	case "uint", "uint32":
+		return float64(r.order.Uint32(buf)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(r.order.Uint32(buf))), nil
+	default: // "double", "float64"
+		return math.Float64frombits(r.order.Uint64(buf)), nil
+	}
+}
+
+// parseFloats parses the given fields as float64 values.
+func parseFloats(fields []string) ([]float64, error) {
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		var err error
+		values[i], err = strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid number %q", field)
+		}
+	}
+	return values, nil
+}
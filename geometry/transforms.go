@@ -0,0 +1,55 @@
+package geometry
+
+import (
+	"github.com/gomlx/gnn/graph"
+	"github.com/pkg/errors"
+)
+
+// RadiusGraph returns a graph.Transform that replaces the edges of a graph by the edges connecting nodes whose
+// Positions are within the given radius (see RadiusEdges). Any previous EdgeFeatures are dropped.
+//
+// If loop is false, the self-loops (each node is within the radius of itself) are removed, as in PyG's RadiusGraph.
+//
+// It is registered as the "radius_graph" transform (see graph.RegisterTransform), with the parameters
+// "radius" (required) and "loop" (default false).
+func RadiusGraph(radius float64, loop bool) graph.Transform {
+	return graph.TransformFunc(func(g *graph.Graph) error {
+		if g.Positions == nil {
+			return errors.New("RadiusGraph requires the graph Positions")
+		}
+		// RadiusEdges locks both tensors while reading them, so the target must be a different tensor.
+		edges, err := RadiusEdges(g.Positions, g.Positions.Clone(), radius).Done()
+		if err != nil {
+			return errors.WithMessagef(err, "RadiusGraph(radius=%g)", radius)
+		}
+		if !loop {
+			edges, _, err = graph.RemoveSelfLoops(edges)
+			if err != nil {
+				return errors.WithMessagef(err, "RadiusGraph(radius=%g)", radius)
+			}
+		}
+		g.Edges = edges
+		g.EdgeFeatures = nil
+		return nil
+	})
+}
+
+func init() {
+	graph.RegisterTransform("radius_graph", func(params graph.TransformParams) (graph.Transform, error) {
+		if err := params.CheckKeys("radius", "loop"); err != nil {
+			return nil, err
+		}
+		if _, found := params["radius"]; !found {
+			return nil, errors.New("parameter \"radius\" is required")
+		}
+		radius, err := params.Float("radius", 0)
+		if err != nil {
+			return nil, err
+		}
+		loop, err := params.Bool("loop", false)
+		if err != nil {
+			return nil, err
+		}
+		return RadiusGraph(radius, loop), nil
+	})
+}
@@ -0,0 +1,36 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/gomlx/gnn/graph"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRadiusGraph(t *testing.T) {
+	newGraph := func() *graph.Graph {
+		g := graph.New(3, nil)
+		g.Positions = tensors.FromValue([][]float32{{0, 0}, {1, 0}, {5, 0}})
+		g.NodeFeatures = tensors.FromValue([][]float32{{1, 1}, {1, 3}, {0, 0}})
+		return g
+	}
+	g := newGraph()
+	require.NoError(t, RadiusGraph(1.5, false).Apply(g))
+	require.NoError(t, graph.SortEdgesBySource(g.Edges))
+	require.Equal(t, [][]int32{{0, 1}, {1, 0}}, g.Edges.Value())
+
+	// Configured from a specification string, chained with graph transforms.
+	transform, err := graph.ParseTransforms("radius_graph(radius=1.5, loop=true), sort_edges, normalize_features")
+	require.NoError(t, err)
+	g = newGraph()
+	require.NoError(t, transform.Apply(g))
+	require.Equal(t, [][]int32{{0, 0, 1, 1, 2}, {0, 1, 0, 1, 2}}, g.Edges.Value())
+	require.Equal(t, [][]float32{{0.5, 0.5}, {0.25, 0.75}, {0, 0}}, g.NodeFeatures.Value())
+
+	_, err = graph.ParseTransforms("radius_graph")
+	require.Error(t, err)
+	_, err = graph.ParseTransforms("radius_graph(radius=1, k=3)")
+	require.Error(t, err)
+	require.Error(t, RadiusGraph(1, false).Apply(graph.New(3, nil)))
+}
@@ -0,0 +1,119 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+	"github.com/pkg/errors"
+)
+
+// TripletAngles returns the angle at node j of each triplet k->j->i, that is, the angle between the
+// vectors (positions[k] - positions[j]) and (positions[i] - positions[j]), in radians in the range [0, pi].
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - positions: shaped [numNodes, dimension], where the dimension is usually 2 or 3.
+//     Only float32 and float64 data types are supported.
+//   - edges: shaped [2, numEdges]Int32, where edge_e connects source node edges[0][e] to target node edges[1][e].
+//   - edgeKJ, edgeJI: shaped [numTriplets]Int32, the indices of the edges k->j and j->i of each triplet, as
+//     returned by graph.Triplets.
+//
+// It returns a tensor shaped [numTriplets] with the same dtype as positions. If either vector has zero length,
+// the angle is 0.
+func TripletAngles(positions, edges, edgeKJ, edgeJI *tensors.Tensor) (*tensors.Tensor, error) {
+	if positions.Shape().Rank() != 2 {
+		return nil, errors.Errorf("positions (%s) must be rank 2: [numNodes, dimension]", positions.Shape())
+	}
+	if edges.Shape().Rank() != 2 || edges.Shape().Dimensions[0] != 2 || edges.DType() != dtypes.Int32 {
+		return nil, errors.Errorf("edges (%s) must be shaped [2, numEdges]Int32", edges.Shape())
+	}
+	if edgeKJ.Shape().Rank() != 1 || edgeKJ.DType() != dtypes.Int32 || !edgeKJ.Shape().Equal(edgeJI.Shape()) {
+		return nil, errors.Errorf("edgeKJ (%s) and edgeJI (%s) must both be shaped [numTriplets]Int32",
+			edgeKJ.Shape(), edgeJI.Shape())
+	}
+	numNodes := positions.Shape().Dimensions[0]
+	dimension := positions.Shape().Dimensions[1]
+	numEdges := edges.Shape().Dimensions[1]
+	numTriplets := edgeKJ.Shape().Dimensions[0]
+
+	var err error
+	angles := tensors.FromShape(shapes.Make(positions.DType(), numTriplets))
+	tensors.ConstFlatData[int32](edges, func(flatEdges []int32) {
+		tensors.ConstFlatData[int32](edgeKJ, func(kj []int32) {
+			tensors.ConstFlatData[int32](edgeJI, func(ji []int32) {
+				switch positions.DType() {
+				case dtypes.Float32:
+					tensors.ConstFlatData[float32](positions, func(flatPositions []float32) {
+						tensors.MutableFlatData[float32](angles, func(flatAngles []float32) {
+							err = tripletAnglesImpl(flatPositions, flatEdges, kj, ji, numNodes, numEdges, dimension, flatAngles)
+						})
+					})
+				case dtypes.Float64:
+					tensors.ConstFlatData[float64](positions, func(flatPositions []float64) {
+						tensors.MutableFlatData[float64](angles, func(flatAngles []float64) {
+							err = tripletAnglesImpl(flatPositions, flatEdges, kj, ji, numNodes, numEdges, dimension, flatAngles)
+						})
+					})
+				default:
+					err = errors.Errorf("DType of the positions (%s) must be either Float32 or Float64", positions.Shape())
+				}
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return angles, nil
+}
+
+func tripletAnglesImpl[T KDTreePointType](positions []T, edges, kj, ji []int32, numNodes, numEdges, dimension int, angles []T) error {
+	sources, targets := edges[:numEdges], edges[numEdges:]
+	for tripletIdx := range kj {
+		edgeKJ, edgeJI := kj[tripletIdx], ji[tripletIdx]
+		if edgeKJ < 0 || int(edgeKJ) >= numEdges || edgeJI < 0 || int(edgeJI) >= numEdges {
+			return errors.Errorf("triplet #%d refers to edges (%d, %d), but there are only %d edges",
+				tripletIdx, edgeKJ, edgeJI, numEdges)
+		}
+		k, j, i := sources[edgeKJ], targets[edgeKJ], targets[edgeJI]
+		if sources[edgeJI] != j {
+			return errors.Errorf("triplet #%d edges %d (%d->%d) and %d (%d->%d) are not consecutive",
+				tripletIdx, edgeKJ, k, j, edgeJI, sources[edgeJI], i)
+		}
+		if int(k) >= numNodes || int(j) >= numNodes || int(i) >= numNodes || k < 0 || j < 0 || i < 0 {
+			return errors.Errorf("triplet #%d refers to nodes (%d, %d, %d), but there are only %d nodes",
+				tripletIdx, k, j, i, numNodes)
+		}
+		pK := positions[int(k)*dimension : int(k+1)*dimension]
+		pJ := positions[int(j)*dimension : int(j+1)*dimension]
+		pI := positions[int(i)*dimension : int(i+1)*dimension]
+		var a, b [3]float64
+		var dot, normA2, normB2 float64
+		for axis := range dimension {
+			aAxis := float64(pK[axis] - pJ[axis])
+			bAxis := float64(pI[axis] - pJ[axis])
+			if axis < 3 {
+				a[axis], b[axis] = aAxis, bAxis
+			}
+			dot += aAxis * bAxis
+			normA2 += aAxis * aAxis
+			normB2 += bAxis * bAxis
+		}
+		// atan2(|a x b|, a . b) is numerically more stable than acos for angles close to 0 or pi.
+		var cross float64
+		switch dimension {
+		case 2:
+			cross = math.Abs(a[0]*b[1] - a[1]*b[0])
+		case 3:
+			cross = math.Sqrt(sqr(a[1]*b[2]-a[2]*b[1]) + sqr(a[2]*b[0]-a[0]*b[2]) + sqr(a[0]*b[1]-a[1]*b[0]))
+		default:
+			cross = math.Sqrt(max(normA2*normB2-dot*dot, 0))
+		}
+		angles[tripletIdx] = T(math.Atan2(cross, dot))
+	}
+	return nil
+}
+
+func sqr(x float64) float64 { return x * x }
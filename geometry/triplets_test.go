@@ -0,0 +1,32 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gomlx/gnn/graph"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTripletAngles(t *testing.T) {
+	positions := tensors.FromValue([][]float64{{0, 0}, {1, 0}, {1, 1}})
+	// Edges: 0->1, 1->2, 2->0, 1->0
+	edges := tensors.FromValue([][]int32{{0, 1, 2, 1}, {1, 2, 0, 0}})
+	edgeKJ, edgeJI, err := graph.Triplets(edges)
+	require.NoError(t, err)
+	angles, err := TripletAngles(positions, edges, edgeKJ, edgeJI)
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float64{math.Pi / 2, math.Pi / 4, math.Pi / 4}, angles.Value(), 1e-9)
+
+	// 3D, float32: the angle at node 0 between the x and y axes.
+	positions = tensors.FromValue([][]float32{{0, 0, 0}, {2, 0, 0}, {0, 3, 0}})
+	edges = tensors.FromValue([][]int32{{1, 0}, {0, 2}})
+	angles, err = TripletAngles(positions, edges, tensors.FromValue([]int32{0}), tensors.FromValue([]int32{1}))
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float32{math.Pi / 2}, angles.Value(), 1e-6)
+
+	// Edges not consecutive.
+	_, err = TripletAngles(positions, edges, tensors.FromValue([]int32{1}), tensors.FromValue([]int32{0}))
+	require.Error(t, err)
+}
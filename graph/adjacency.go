@@ -0,0 +1,240 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// CompressedEdges holds the edges of a graph in compressed sparse row (CSR) or column (CSC) format, as created
+// by ToCSR and ToCSC.
+//
+// In CSR format, the edges are grouped by source node: the outgoing edges of node n connect it to the target
+// nodes Indices[Ptr[n]:Ptr[n+1]]. In CSC format (ByTarget is true), the edges are grouped by target node, and
+// Indices holds the source nodes instead. Within each node, the edges are sorted by the other node index, and
+// ties (duplicate edges) keep the order of the original edges.
+type CompressedEdges struct {
+	// NumNodes in the graph.
+	NumNodes int
+
+	// Ptr has NumNodes+1 elements: the edges of node n are in the range [Ptr[n], Ptr[n+1]).
+	Ptr []int32
+
+	// Indices has numEdges elements, with the target node (CSR) or the source node (CSC) of each edge.
+	Indices []int32
+
+	// Permutation has numEdges elements, with the index of each edge in the original COO edges tensor.
+	// Use it with GatherRows to carry edge features along.
+	Permutation []int32
+
+	// ByTarget is true for the CSC format, where edges are grouped by target node.
+	ByTarget bool
+}
+
+// ToCSR converts the edges tensor shaped [2, numEdges]Int32 (COO format) to the compressed sparse row format,
+// where edges are grouped by source node.
+//
+// It runs in O(numEdges + numNodes), with two passes of counting sort. It returns an error if the edges are invalid
+// or refer to nodes outside [0, numNodes).
+func ToCSR(edges *tensors.Tensor, numNodes int) (*CompressedEdges, error) {
+	return compressEdges(edges, numNodes, false)
+}
+
+// ToCSC converts the edges tensor shaped [2, numEdges]Int32 (COO format) to the compressed sparse column format,
+// where edges are grouped by target node.
+//
+// See ToCSR for details.
+func ToCSC(edges *tensors.Tensor, numNodes int) (*CompressedEdges, error) {
+	return compressEdges(edges, numNodes, true)
+}
+
+func compressEdges(edges *tensors.Tensor, numNodes int, byTarget bool) (*CompressedEdges, error) {
+	if err := checkEdges(edges); err != nil {
+		return nil, err
+	}
+	if numNodes <= 0 {
+		return nil, fmt.Errorf("invalid number of nodes %d", numNodes)
+	}
+	numEdges := edges.Shape().Dimensions[1]
+	var keys, others []int32
+	var err error
+	tensors.ConstFlatData(edges, func(flat []int32) {
+		for i, nodeIdx := range flat {
+			if nodeIdx < 0 || int(nodeIdx) >= numNodes {
+				err = fmt.Errorf("edge #%d refers to node %d, but there are only %d nodes", i%numEdges, nodeIdx, numNodes)
+				return
+			}
+		}
+		keys = append([]int32(nil), flat[:numEdges]...)
+		others = append([]int32(nil), flat[numEdges:]...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if byTarget {
+		keys, others = others, keys
+	}
+
+	// Radix sort: a stable counting sort by the secondary key, followed by a stable counting sort by the primary key.
+	identity := rangeIndices(0, int32(numEdges))
+	_, bySecondary := countingSort(others, identity, numNodes)
+	ptr, permutation := countingSort(keys, bySecondary, numNodes)
+	indices := make([]int32, numEdges)
+	for i, edgeIdx := range permutation {
+		indices[i] = others[edgeIdx]
+	}
+	return &CompressedEdges{
+		NumNodes:    numNodes,
+		Ptr:         ptr,
+		Indices:     indices,
+		Permutation: permutation,
+		ByTarget:    byTarget,
+	}, nil
+}
+
+// countingSort stably sorts the edge indices in order by keys[edgeIdx], with keys in the range [0, numNodes).
+// It returns the offsets of each key (with numNodes+1 elements) and the sorted edge indices.
+func countingSort(keys, order []int32, numNodes int) (ptr, sorted []int32) {
+	ptr = make([]int32, numNodes+1)
+	for _, key := range keys {
+		ptr[key+1]++
+	}
+	for nodeIdx := range numNodes {
+		ptr[nodeIdx+1] += ptr[nodeIdx]
+	}
+	next := make([]int32, numNodes)
+	copy(next, ptr[:numNodes])
+	sorted = make([]int32, len(order))
+	for _, edgeIdx := range order {
+		key := keys[edgeIdx]
+		sorted[next[key]] = edgeIdx
+		next[key]++
+	}
+	return ptr, sorted
+}
+
+// NumEdges in the compressed representation.
+func (c *CompressedEdges) NumEdges() int {
+	return len(c.Indices)
+}
+
+// ToCOO converts the compressed edges back to an edges tensor shaped [2, numEdges]Int32 (COO format).
+// The edges are in the compressed order: edge i of the output corresponds to edge Permutation[i] of the original
+// edges. So for CSR this is the same as sorting the edges with SortEdgesBySource.
+func (c *CompressedEdges) ToCOO() *tensors.Tensor {
+	numEdges := c.NumEdges()
+	edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	tensors.MutableFlatData(edges, func(flat []int32) {
+		keys, others := flat[:numEdges], flat[numEdges:]
+		if c.ByTarget {
+			keys, others = others, keys
+		}
+		for nodeIdx := range c.NumNodes {
+			for i := c.Ptr[nodeIdx]; i < c.Ptr[nodeIdx+1]; i++ {
+				keys[i] = int32(nodeIdx)
+			}
+		}
+		copy(others, c.Indices)
+	})
+	return edges
+}
+
+// Tensors returns the Ptr, Indices and Permutation as Int32 tensors, to be used as inputs to a computation graph.
+func (c *CompressedEdges) Tensors() (ptr, indices, permutation *tensors.Tensor) {
+	return tensors.FromValue(c.Ptr), tensors.FromValue(c.Indices), tensors.FromValue(c.Permutation)
+}
+
+// Adjacency holds both the CSR and CSC representations of the edges of a graph, for quick access to the outgoing
+// and incoming edges of each node. It is created with NewAdjacency or Graph.Adjacency, and it is immutable.
+type Adjacency struct {
+	// Out holds the edges in CSR format (grouped by source node).
+	Out *CompressedEdges
+
+	// In holds the edges in CSC format (grouped by target node).
+	In *CompressedEdges
+}
+
+// NewAdjacency creates the Adjacency of the edges tensor shaped [2, numEdges]Int32 (COO format).
+func NewAdjacency(edges *tensors.Tensor, numNodes int) (*Adjacency, error) {
+	out, err := ToCSR(edges, numNodes)
+	if err != nil {
+		return nil, err
+	}
+	in, err := ToCSC(edges, numNodes)
+	if err != nil {
+		return nil, err
+	}
+	return &Adjacency{Out: out, In: in}, nil
+}
+
+// Adjacency creates the Adjacency of the graph edges.
+func (g *Graph) Adjacency() (*Adjacency, error) {
+	if g.Edges == nil {
+		return nil, fmt.Errorf("graph has no edges tensor")
+	}
+	return NewAdjacency(g.Edges, g.NumNodes)
+}
+
+// NumNodes in the graph.
+func (a *Adjacency) NumNodes() int {
+	return a.Out.NumNodes
+}
+
+// NumEdges in the graph.
+func (a *Adjacency) NumEdges() int {
+	return a.Out.NumEdges()
+}
+
+// Neighbors returns the target nodes of the outgoing edges of node, sorted. The returned slice is shared with
+// the Adjacency and must not be modified.
+func (a *Adjacency) Neighbors(node int) []int32 {
+	return a.Out.Indices[a.Out.Ptr[node]:a.Out.Ptr[node+1]]
+}
+
+// InNeighbors returns the source nodes of the incoming edges of node, sorted. The returned slice is shared with
+// the Adjacency and must not be modified.
+func (a *Adjacency) InNeighbors(node int) []int32 {
+	return a.In.Indices[a.In.Ptr[node]:a.In.Ptr[node+1]]
+}
+
+// OutEdges returns the indices (in the original edges tensor) of the outgoing edges of node, in the same order as
+// Neighbors. The returned slice is shared with the Adjacency and must not be modified.
+func (a *Adjacency) OutEdges(node int) []int32 {
+	return a.Out.Permutation[a.Out.Ptr[node]:a.Out.Ptr[node+1]]
+}
+
+// InEdges returns the indices (in the original edges tensor) of the incoming edges of node, in the same order as
+// InNeighbors. The returned slice is shared with the Adjacency and must not be modified.
+func (a *Adjacency) InEdges(node int) []int32 {
+	return a.In.Permutation[a.In.Ptr[node]:a.In.Ptr[node+1]]
+}
+
+// OutDegree returns the number of outgoing edges of node.
+func (a *Adjacency) OutDegree(node int) int {
+	return int(a.Out.Ptr[node+1] - a.Out.Ptr[node])
+}
+
+// InDegree returns the number of incoming edges of node.
+func (a *Adjacency) InDegree(node int) int {
+	return int(a.In.Ptr[node+1] - a.In.Ptr[node])
+}
+
+// OutDegrees returns the number of outgoing edges of every node.
+func (a *Adjacency) OutDegrees() []int32 {
+	return ptrToDegrees(a.Out.Ptr)
+}
+
+// InDegrees returns the number of incoming edges of every node.
+func (a *Adjacency) InDegrees() []int32 {
+	return ptrToDegrees(a.In.Ptr)
+}
+
+func ptrToDegrees(ptr []int32) []int32 {
+	degrees := make([]int32, len(ptr)-1)
+	for i := range degrees {
+		degrees[i] = ptr[i+1] - ptr[i]
+	}
+	return degrees
+}
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedEdges(t *testing.T) {
+	edges := tensors.FromValue([][]int32{{2, 0, 2, 0, 1}, {0, 3, 1, 1, 0}})
+	csr, err := ToCSR(edges, 4)
+	require.NoError(t, err)
+	require.Equal(t, []int32{0, 2, 3, 5, 5}, csr.Ptr)
+	require.Equal(t, []int32{1, 3, 0, 0, 1}, csr.Indices)
+	require.Equal(t, []int32{3, 1, 4, 0, 2}, csr.Permutation)
+	require.Equal(t, [][]int32{{0, 0, 1, 2, 2}, {1, 3, 0, 0, 1}}, csr.ToCOO().Value())
+
+	// ToCOO on CSR matches SortEdgesBySource.
+	sorted := edges.Clone()
+	require.NoError(t, SortEdgesBySource(sorted))
+	require.Equal(t, sorted.Value(), csr.ToCOO().Value())
+
+	csc, err := ToCSC(edges, 4)
+	require.NoError(t, err)
+	require.True(t, csc.ByTarget)
+	require.Equal(t, []int32{0, 2, 4, 4, 5}, csc.Ptr)
+	require.Equal(t, []int32{1, 2, 0, 2, 0}, csc.Indices)
+	require.Equal(t, []int32{4, 0, 3, 2, 1}, csc.Permutation)
+	require.Equal(t, [][]int32{{1, 2, 0, 2, 0}, {0, 0, 1, 1, 3}}, csc.ToCOO().Value())
+
+	ptr, indices, permutation := csr.Tensors()
+	require.Equal(t, csr.Ptr, ptr.Value())
+	require.Equal(t, csr.Indices, indices.Value())
+	require.Equal(t, csr.Permutation, permutation.Value())
+
+	// Node out of range.
+	_, err = ToCSR(edges, 3)
+	require.Error(t, err)
+}
+
+func TestAdjacency(t *testing.T) {
+	g := New(4, tensors.FromValue([][]int32{{2, 0, 2, 0, 1}, {0, 3, 1, 1, 0}}))
+	g.EdgeFeatures = tensors.FromValue([]float32{20, 3, 21, 1, 10})
+	adj, err := g.Adjacency()
+	require.NoError(t, err)
+	require.Equal(t, 4, adj.NumNodes())
+	require.Equal(t, 5, adj.NumEdges())
+	require.Equal(t, []int32{1, 3}, adj.Neighbors(0))
+	require.Equal(t, []int32{1, 2}, adj.InNeighbors(0))
+	require.Equal(t, []int32{3, 1}, adj.OutEdges(0))
+	require.Equal(t, []int32{4, 0}, adj.InEdges(0))
+	require.Empty(t, adj.Neighbors(3))
+	require.Equal(t, 2, adj.OutDegree(2))
+	require.Equal(t, 0, adj.InDegree(2))
+	require.Equal(t, []int32{2, 1, 2, 0}, adj.OutDegrees())
+	require.Equal(t, []int32{2, 2, 0, 1}, adj.InDegrees())
+
+	// Edge features follow the permutation.
+	require.Equal(t, []float32{1, 3, 10, 20, 21}, GatherRows(g.EdgeFeatures, adj.Out.Permutation).Value())
+}
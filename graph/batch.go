@@ -0,0 +1,265 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// BatchedGraph is the disjoint union of several graphs into one larger disconnected graph, as created by Batch.
+type BatchedGraph struct {
+	// Graph with all the nodes and edges of the batched graphs. The node indices of the edges of the i-th graph are
+	// offset by NodePtr[i].
+	*Graph
+
+	// NumGraphs in the batch.
+	NumGraphs int
+
+	// NodeBatch shaped [NumNodes]Int32 holds the index of the graph of each node. It can be used as the indices
+	// of a scatter operation (e.g. graph.ScatterSum in GoMLX) to pool node values per graph.
+	NodeBatch *tensors.Tensor
+
+	// NodePtr shaped [NumGraphs+1]Int32 holds the offset of the first node of each graph, plus the total number of
+	// nodes in the last element. So the nodes of the i-th graph are in the range [NodePtr[i], NodePtr[i+1]).
+	NodePtr *tensors.Tensor
+
+	// EdgePtr shaped [NumGraphs+1]Int32 holds the offset of the first edge of each graph, plus the total number of
+	// edges in the last element. So the edges of the i-th graph are in the range [EdgePtr[i], EdgePtr[i+1]).
+	EdgePtr *tensors.Tensor
+
+	// NodeLevelLabels indicates whether the Labels are per node (concatenated along the first axis) or per graph
+	// (stacked on a new first axis, shaped [NumGraphs, ...]).
+	NodeLevelLabels bool
+}
+
+// BatchConfig is created with Batch and once fully configured, can be executed with Done.
+type BatchConfig struct {
+	graphs          []*Graph
+	nodeLevelLabels bool
+}
+
+// Batch combines the given graphs into one large disconnected graph (their disjoint union), typically used to
+// train on many small graphs at once (e.g. for graph classification).
+//
+// The nodes (and node features and positions) of the graphs are concatenated in order, and the edges (and edge
+// features) are concatenated with their node indices offset by the number of nodes of the previous graphs.
+// Optional tensors must be either set for all graphs or for none.
+//
+// Labels are considered graph-level by default, and they are stacked (so they must all have the same shape) on a
+// new first axis. See BatchConfig.NodeLevelLabels to concatenate node-level labels instead.
+//
+// It returns a configuration that can be optionally configured. Call BatchConfig.Done to perform the operation.
+// See Unbatch for the inverse operation.
+func Batch(graphs ...*Graph) *BatchConfig {
+	return &BatchConfig{graphs: graphs}
+}
+
+// NodeLevelLabels configures the labels of the graphs as node-level: they must be shaped [NumNodes, ...], and they
+// are concatenated along the first axis, like the node features.
+func (c *BatchConfig) NodeLevelLabels() *BatchConfig {
+	c.nodeLevelLabels = true
+	return c
+}
+
+// Done performs the batching as configured.
+func (c *BatchConfig) Done() (*BatchedGraph, error) {
+	graphs := c.graphs
+	if len(graphs) == 0 {
+		return nil, fmt.Errorf("no graphs to batch")
+	}
+	numGraphs := len(graphs)
+	nodePtr := make([]int32, numGraphs+1)
+	edgePtr := make([]int32, numGraphs+1)
+	var nodeFeatures, edgeFeatures, positions, labels []*tensors.Tensor
+	for i, g := range graphs {
+		if err := g.Validate(); err != nil {
+			return nil, fmt.Errorf("graph #%d: %w", i, err)
+		}
+		if c.nodeLevelLabels {
+			if err := checkLeadingDim("node-level Labels", g.Labels, g.NumNodes); err != nil {
+				return nil, fmt.Errorf("graph #%d: %w", i, err)
+			}
+		}
+		nodePtr[i+1] = nodePtr[i] + int32(g.NumNodes)
+		edgePtr[i+1] = edgePtr[i] + int32(g.NumEdges())
+		nodeFeatures = append(nodeFeatures, g.NodeFeatures)
+		edgeFeatures = append(edgeFeatures, g.EdgeFeatures)
+		positions = append(positions, g.Positions)
+		labels = append(labels, g.Labels)
+	}
+	numNodes, numEdges := int(nodePtr[numGraphs]), int(edgePtr[numGraphs])
+
+	edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	nodeBatch := make([]int32, numNodes)
+	tensors.MutableFlatData(edges, func(flatEdges []int32) {
+		for i, g := range graphs {
+			graphNumEdges := g.NumEdges()
+			offset := nodePtr[i]
+			tensors.ConstFlatData(g.Edges, func(flat []int32) {
+				for j := range graphNumEdges {
+					flatEdges[int(edgePtr[i])+j] = flat[j] + offset
+					flatEdges[numEdges+int(edgePtr[i])+j] = flat[graphNumEdges+j] + offset
+				}
+			})
+			for nodeIdx := nodePtr[i]; nodeIdx < nodePtr[i+1]; nodeIdx++ {
+				nodeBatch[nodeIdx] = int32(i)
+			}
+		}
+	})
+
+	batched := &BatchedGraph{
+		Graph:           New(numNodes, edges),
+		NumGraphs:       numGraphs,
+		NodeBatch:       tensors.FromValue(nodeBatch),
+		NodePtr:         tensors.FromValue(nodePtr),
+		EdgePtr:         tensors.FromValue(edgePtr),
+		NodeLevelLabels: c.nodeLevelLabels,
+	}
+	var err error
+	if batched.NodeFeatures, err = concatenateOptional("node features", nodeFeatures); err != nil {
+		return nil, err
+	}
+	if batched.EdgeFeatures, err = concatenateOptional("edge features", edgeFeatures); err != nil {
+		return nil, err
+	}
+	if batched.Positions, err = concatenateOptional("positions", positions); err != nil {
+		return nil, err
+	}
+	if c.nodeLevelLabels {
+		batched.Labels, err = concatenateOptional("labels", labels)
+	} else {
+		batched.Labels, err = stackOptional("labels", labels)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return batched, nil
+}
+
+// stackOptional stacks the tensors on a new first axis if they are all set, returns nil if none are set,
+// and returns an error if only some are set, or if they have different shapes.
+func stackOptional(name string, ts []*tensors.Tensor) (*tensors.Tensor, error) {
+	numSet := 0
+	for _, t := range ts {
+		if t != nil {
+			numSet++
+		}
+	}
+	if numSet == 0 {
+		return nil, nil
+	}
+	if numSet != len(ts) {
+		return nil, fmt.Errorf("%s are set only for some of the graphs (%d out of %d), they must be set for all or none",
+			name, numSet, len(ts))
+	}
+	shape := ts[0].Shape()
+	for i, t := range ts {
+		if !t.Shape().Equal(shape) {
+			return nil, fmt.Errorf("can't stack %s: tensor #%d is shaped %s, but tensor #0 is shaped %s", name, i, t.Shape(), shape)
+		}
+	}
+	output := tensors.FromShape(shapes.Make(shape.DType, append([]int{len(ts)}, shape.Dimensions...)...))
+	output.MutableBytes(func(outputData []byte) {
+		var pos int
+		for _, t := range ts {
+			t.ConstBytes(func(data []byte) {
+				pos += copy(outputData[pos:], data)
+			})
+		}
+	})
+	return output, nil
+}
+
+// Unbatch splits a BatchedGraph back into the individual graphs. It is the inverse of Batch.
+func Unbatch(batched *BatchedGraph) ([]*Graph, error) {
+	if batched.Graph == nil || batched.NodePtr == nil || batched.EdgePtr == nil {
+		return nil, fmt.Errorf("invalid BatchedGraph, Graph, NodePtr and EdgePtr must be set")
+	}
+	if err := batched.Validate(); err != nil {
+		return nil, err
+	}
+	numGraphs := batched.NumGraphs
+	if err := checkLeadingDim("NodePtr", batched.NodePtr, numGraphs+1); err != nil {
+		return nil, err
+	}
+	if err := checkLeadingDim("EdgePtr", batched.EdgePtr, numGraphs+1); err != nil {
+		return nil, err
+	}
+	nodePtr := tensors.CopyFlatData[int32](batched.NodePtr)
+	edgePtr := tensors.CopyFlatData[int32](batched.EdgePtr)
+	if int(nodePtr[numGraphs]) != batched.NumNodes || int(edgePtr[numGraphs]) != batched.NumEdges() {
+		return nil, fmt.Errorf("NodePtr and EdgePtr don't match the number of nodes (%d) and edges (%d) of the batched graph",
+			batched.NumNodes, batched.NumEdges())
+	}
+	sources, targets := batched.Sources(), batched.Targets()
+	graphs := make([]*Graph, numGraphs)
+	for i := range numGraphs {
+		nodeStart, nodeEnd := nodePtr[i], nodePtr[i+1]
+		edgeStart, edgeEnd := edgePtr[i], edgePtr[i+1]
+		if nodeEnd <= nodeStart || edgeEnd <= edgeStart {
+			return nil, fmt.Errorf("graph #%d in the batch has no nodes or no edges", i)
+		}
+		numEdges := int(edgeEnd - edgeStart)
+		edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+		var err error
+		tensors.MutableFlatData(edges, func(flat []int32) {
+			for j := range numEdges {
+				source, target := sources[int(edgeStart)+j], targets[int(edgeStart)+j]
+				if source < nodeStart || source >= nodeEnd || target < nodeStart || target >= nodeEnd {
+					err = fmt.Errorf("edge #%d of graph #%d in the batch connects nodes outside of the graph", j, i)
+					return
+				}
+				flat[j] = source - nodeStart
+				flat[numEdges+j] = target - nodeStart
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		g := New(int(nodeEnd-nodeStart), edges)
+		nodeRange := rangeIndices(nodeStart, nodeEnd)
+		edgeRange := rangeIndices(edgeStart, edgeEnd)
+		if batched.NodeFeatures != nil {
+			g.NodeFeatures = GatherRows(batched.NodeFeatures, nodeRange)
+		}
+		if batched.Positions != nil {
+			g.Positions = GatherRows(batched.Positions, nodeRange)
+		}
+		if batched.EdgeFeatures != nil {
+			g.EdgeFeatures = GatherRows(batched.EdgeFeatures, edgeRange)
+		}
+		if batched.Labels != nil {
+			if batched.NodeLevelLabels {
+				g.Labels = GatherRows(batched.Labels, nodeRange)
+			} else {
+				g.Labels = unstackRow(batched.Labels, i)
+			}
+		}
+		graphs[i] = g
+	}
+	return graphs, nil
+}
+
+// rangeIndices returns the indices in the range [start, end).
+func rangeIndices(start, end int32) []int32 {
+	indices := make([]int32, end-start)
+	for i := range indices {
+		indices[i] = start + int32(i)
+	}
+	return indices
+}
+
+// unstackRow returns the row-th slice along the first axis of t, without the first axis.
+func unstackRow(t *tensors.Tensor, row int) *tensors.Tensor {
+	dims := t.Shape().Dimensions[1:]
+	output := tensors.FromShape(shapes.Make(t.DType(), dims...))
+	rowSize := int(output.Shape().Memory())
+	t.ConstBytes(func(data []byte) {
+		output.MutableBytes(func(outputData []byte) {
+			copy(outputData, data[row*rowSize:(row+1)*rowSize])
+		})
+	})
+	return output
+}
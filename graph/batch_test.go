@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	g0 := New(2, tensors.FromValue([][]int32{{0, 1}, {1, 0}}))
+	g0.NodeFeatures = tensors.FromValue([][]float32{{0}, {1}})
+	g0.EdgeFeatures = tensors.FromValue([]int32{10, 11})
+	g0.Labels = tensors.FromScalar(int32(7))
+	g1 := New(3, tensors.FromValue([][]int32{{0, 2, 2}, {1, 0, 1}}))
+	g1.NodeFeatures = tensors.FromValue([][]float32{{2}, {3}, {4}})
+	g1.EdgeFeatures = tensors.FromValue([]int32{12, 13, 14})
+	g1.Labels = tensors.FromScalar(int32(8))
+
+	batched, err := Batch(g0, g1).Done()
+	require.NoError(t, err)
+	require.NoError(t, batched.Validate())
+	require.Equal(t, 2, batched.NumGraphs)
+	require.Equal(t, 5, batched.NumNodes)
+	require.Equal(t, [][]int32{{0, 1, 2, 4, 4}, {1, 0, 3, 2, 3}}, batched.Edges.Value())
+	require.Equal(t, [][]float32{{0}, {1}, {2}, {3}, {4}}, batched.NodeFeatures.Value())
+	require.Equal(t, []int32{10, 11, 12, 13, 14}, batched.EdgeFeatures.Value())
+	require.Equal(t, []int32{7, 8}, batched.Labels.Value())
+	require.False(t, batched.NodeLevelLabels)
+	require.Equal(t, []int32{0, 0, 1, 1, 1}, batched.NodeBatch.Value())
+	require.Equal(t, []int32{0, 2, 5}, batched.NodePtr.Value())
+	require.Equal(t, []int32{0, 2, 5}, batched.EdgePtr.Value())
+
+	graphs, err := Unbatch(batched)
+	require.NoError(t, err)
+	require.Len(t, graphs, 2)
+	for i, g := range []*Graph{g0, g1} {
+		require.Equal(t, g.NumNodes, graphs[i].NumNodes)
+		require.Equal(t, g.Edges.Value(), graphs[i].Edges.Value())
+		require.Equal(t, g.NodeFeatures.Value(), graphs[i].NodeFeatures.Value())
+		require.Equal(t, g.EdgeFeatures.Value(), graphs[i].EdgeFeatures.Value())
+		require.Equal(t, g.Labels.Value(), graphs[i].Labels.Value())
+	}
+
+	// Node-level labels.
+	g0.Labels = tensors.FromValue([]int32{0, 1})
+	g1.Labels = tensors.FromValue([]int32{2, 3, 4})
+	batched, err = Batch(g0, g1).NodeLevelLabels().Done()
+	require.NoError(t, err)
+	require.True(t, batched.NodeLevelLabels)
+	require.Equal(t, []int32{0, 1, 2, 3, 4}, batched.Labels.Value())
+	graphs, err = Unbatch(batched)
+	require.NoError(t, err)
+	require.Equal(t, []int32{2, 3, 4}, graphs[1].Labels.Value())
+	_, err = Batch(g0, g1).Done()
+	require.Error(t, err, "labels of different shapes can't be stacked as graph-level labels")
+
+	// The label level is explicit, even if graph-level labels happen to have NumNodes rows.
+	single0 := New(1, tensors.FromValue([][]int32{{0}, {0}}))
+	single0.Labels = tensors.FromValue([]int32{5})
+	single1 := New(1, tensors.FromValue([][]int32{{0}, {0}}))
+	single1.Labels = tensors.FromValue([]int32{6})
+	batched, err = Batch(single0, single1).Done()
+	require.NoError(t, err)
+	require.False(t, batched.NodeLevelLabels)
+	require.Equal(t, [][]int32{{5}, {6}}, batched.Labels.Value())
+	graphs, err = Unbatch(batched)
+	require.NoError(t, err)
+	require.Equal(t, []int32{6}, graphs[1].Labels.Value())
+	batched, err = Batch(single0, single1).NodeLevelLabels().Done()
+	require.NoError(t, err)
+	require.True(t, batched.NodeLevelLabels)
+	require.Equal(t, []int32{5, 6}, batched.Labels.Value())
+	single1.Labels = tensors.FromValue([]int32{6, 7})
+	_, err = Batch(single0, single1).NodeLevelLabels().Done()
+	require.Error(t, err)
+
+	// Features must be set for all or none.
+	g1.NodeFeatures = nil
+	_, err = Batch(g0, g1).NodeLevelLabels().Done()
+	require.Error(t, err)
+	_, err = Batch().Done()
+	require.Error(t, err)
+}
@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// Reduction defines how the feature rows of duplicate edges are combined by CoalesceEdges.
+type Reduction int
+
+const (
+	// ReduceSum sums the feature rows of the duplicate edges.
+	ReduceSum Reduction = iota
+
+	// ReduceMean averages the feature rows of the duplicate edges. For integer dtypes the result is truncated.
+	ReduceMean
+
+	// ReduceMax takes the element-wise maximum of the feature rows of the duplicate edges.
+	ReduceMax
+
+	// ReduceMin takes the element-wise minimum of the feature rows of the duplicate edges.
+	ReduceMin
+
+	// ReduceFirst takes the feature row of the first occurrence of the edge. It works with any dtype.
+	ReduceFirst
+)
+
+// String implements fmt.Stringer.
+func (r Reduction) String() string {
+	switch r {
+	case ReduceSum:
+		return "ReduceSum"
+	case ReduceMean:
+		return "ReduceMean"
+	case ReduceMax:
+		return "ReduceMax"
+	case ReduceMin:
+		return "ReduceMin"
+	case ReduceFirst:
+		return "ReduceFirst"
+	default:
+		return fmt.Sprintf("Reduction(%d)", int(r))
+	}
+}
+
+// CoalesceEdges merges duplicate (parallel) edges, and reduces their edge features rows with the given reduction.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - edgeFeatures: shaped [numEdges, ...], or nil if there are no features. Except for ReduceFirst, that works with
+//     any dtype, the features must have a Go native numeric dtype (not complex or half-precision floats).
+//   - reduce: how to combine the features of duplicate edges.
+//
+// It returns the unique edges sorted by source and then by target (as in SortEdgesBySource), and the reduced
+// features shaped [numUniqueEdges, ...] (nil if edgeFeatures is nil).
+func CoalesceEdges(edges, edgeFeatures *tensors.Tensor, reduce Reduction) (
+	coalescedEdges, coalescedFeatures *tensors.Tensor, err error) {
+	if err = checkEdges(edges); err != nil {
+		return nil, nil, err
+	}
+	numEdges := edges.Shape().Dimensions[1]
+	if err = checkLeadingDim("edgeFeatures", edgeFeatures, numEdges); err != nil {
+		return nil, nil, err
+	}
+	if reduce < ReduceSum || reduce > ReduceFirst {
+		return nil, nil, fmt.Errorf("invalid reduction %s", reduce)
+	}
+
+	// Stable sort of the edges, and then group the duplicates: the edges of group g are order[groupPtr[g]:groupPtr[g+1]].
+	keys := make([]uint64, numEdges)
+	tensors.ConstFlatData(edges, func(flat []int32) {
+		for i := range numEdges {
+			keys[i] = edgeKey(flat[i], flat[numEdges+i])
+		}
+	})
+	order := rangeIndices(0, int32(numEdges))
+	slices.SortStableFunc(order, func(a, b int32) int { return cmp.Compare(keys[a], keys[b]) })
+	var uniqueKeys []uint64
+	var groupPtr, firsts []int32
+	for i, edgeIdx := range order {
+		if i > 0 && keys[edgeIdx] == uniqueKeys[len(uniqueKeys)-1] {
+			continue
+		}
+		uniqueKeys = append(uniqueKeys, keys[edgeIdx])
+		groupPtr = append(groupPtr, int32(i))
+		firsts = append(firsts, edgeIdx)
+	}
+	groupPtr = append(groupPtr, int32(numEdges))
+	coalescedEdges = keysToEdges(uniqueKeys)
+	if edgeFeatures == nil {
+		return coalescedEdges, nil, nil
+	}
+	if reduce == ReduceFirst {
+		return coalescedEdges, GatherRows(edgeFeatures, firsts), nil
+	}
+
+	dims := edgeFeatures.Shape().Clone().Dimensions
+	dims[0] = len(uniqueKeys)
+	coalescedFeatures = tensors.FromShape(shapes.Make(edgeFeatures.DType(), dims...))
+	rowSize := edgeFeatures.Shape().Size() / max(numEdges, 1)
+	edgeFeatures.ConstFlatData(func(flatAny any) {
+		coalescedFeatures.MutableFlatData(func(outputAny any) {
+			switch flat := flatAny.(type) {
+			case []float32:
+				reduceGroups(flat, outputAny.([]float32), order, groupPtr, rowSize, reduce)
+			case []float64:
+				reduceGroups(flat, outputAny.([]float64), order, groupPtr, rowSize, reduce)
+			case []int:
+				reduceGroups(flat, outputAny.([]int), order, groupPtr, rowSize, reduce)
+			case []int8:
+				reduceGroups(flat, outputAny.([]int8), order, groupPtr, rowSize, reduce)
+			case []int16:
+				reduceGroups(flat, outputAny.([]int16), order, groupPtr, rowSize, reduce)
+			case []int32:
+				reduceGroups(flat, outputAny.([]int32), order, groupPtr, rowSize, reduce)
+			case []int64:
+				reduceGroups(flat, outputAny.([]int64), order, groupPtr, rowSize, reduce)
+			case []uint8:
+				reduceGroups(flat, outputAny.([]uint8), order, groupPtr, rowSize, reduce)
+			case []uint16:
+				reduceGroups(flat, outputAny.([]uint16), order, groupPtr, rowSize, reduce)
+			case []uint32:
+				reduceGroups(flat, outputAny.([]uint32), order, groupPtr, rowSize, reduce)
+			case []uint64:
+				reduceGroups(flat, outputAny.([]uint64), order, groupPtr, rowSize, reduce)
+			default:
+				err = fmt.Errorf("CoalesceEdges with %s doesn't support edge features with dtype %s", reduce, edgeFeatures.DType())
+			}
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return coalescedEdges, coalescedFeatures, nil
+}
+
+// reduceGroups reduces the feature rows of each group of edges into the corresponding output row.
+func reduceGroups[T dtypes.NumberNotComplex](features, output []T, order, groupPtr []int32, rowSize int, reduce Reduction) {
+	for group := range len(groupPtr) - 1 {
+		outputRow := output[group*rowSize : (group+1)*rowSize]
+		groupEdges := order[groupPtr[group]:groupPtr[group+1]]
+		copy(outputRow, features[int(groupEdges[0])*rowSize:int(groupEdges[0]+1)*rowSize])
+		for _, edgeIdx := range groupEdges[1:] {
+			row := features[int(edgeIdx)*rowSize : int(edgeIdx+1)*rowSize]
+			for i, value := range row {
+				switch reduce {
+				case ReduceSum, ReduceMean:
+					outputRow[i] += value
+				case ReduceMax:
+					outputRow[i] = max(outputRow[i], value)
+				case ReduceMin:
+					outputRow[i] = min(outputRow[i], value)
+				}
+			}
+		}
+		if reduce == ReduceMean && len(groupEdges) > 1 {
+			count := T(len(groupEdges))
+			for i := range outputRow {
+				outputRow[i] /= count
+			}
+		}
+	}
+}
+
+// ParseReduction parses the name of a reduction: one of "sum", "mean", "max", "min" or "first".
+func ParseReduction(name string) (Reduction, error) {
+	switch name {
+	case "sum":
+		return ReduceSum, nil
+	case "mean":
+		return ReduceMean, nil
+	case "max":
+		return ReduceMax, nil
+	case "min":
+		return ReduceMin, nil
+	case "first":
+		return ReduceFirst, nil
+	default:
+		return 0, fmt.Errorf("unknown reduction %q, valid values are \"sum\", \"mean\", \"max\", \"min\" and \"first\"", name)
+	}
+}
@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalesceEdges(t *testing.T) {
+	edges := tensors.FromValue([][]int32{{1, 0, 1, 0, 1}, {2, 1, 2, 3, 2}})
+	features := tensors.FromValue([][]float32{{1, 10}, {2, 20}, {3, 5}, {4, 40}, {8, 15}})
+	wantEdges := [][]int32{{0, 0, 1}, {1, 3, 2}}
+	for reduce, want := range map[Reduction][][]float32{
+		ReduceSum:   {{2, 20}, {4, 40}, {12, 30}},
+		ReduceMean:  {{2, 20}, {4, 40}, {4, 10}},
+		ReduceMax:   {{2, 20}, {4, 40}, {8, 15}},
+		ReduceMin:   {{2, 20}, {4, 40}, {1, 5}},
+		ReduceFirst: {{2, 20}, {4, 40}, {1, 10}},
+	} {
+		coalescedEdges, coalescedFeatures, err := CoalesceEdges(edges, features, reduce)
+		require.NoError(t, err, "reduce=%s", reduce)
+		require.Equal(t, wantEdges, coalescedEdges.Value(), "reduce=%s", reduce)
+		require.Equal(t, want, coalescedFeatures.Value(), "reduce=%s", reduce)
+	}
+
+	// Integer features and no features.
+	_, coalescedFeatures, err := CoalesceEdges(edges, tensors.FromValue([]int32{1, 2, 2, 4, 2}), ReduceMean)
+	require.NoError(t, err)
+	require.Equal(t, []int32{2, 4, 1}, coalescedFeatures.Value())
+	coalescedEdges, coalescedFeatures, err := CoalesceEdges(edges, nil, ReduceSum)
+	require.NoError(t, err)
+	require.Equal(t, wantEdges, coalescedEdges.Value())
+	require.Nil(t, coalescedFeatures)
+
+	// Errors.
+	_, _, err = CoalesceEdges(edges, tensors.FromValue([]float32{1, 2}), ReduceSum)
+	require.Error(t, err)
+	_, _, err = CoalesceEdges(edges, features, Reduction(17))
+	require.Error(t, err)
+	_, _, err = CoalesceEdges(edges, tensors.FromValue([]bool{true, false, true, false, true}), ReduceMax)
+	require.Error(t, err)
+	_, coalescedFeatures, err = CoalesceEdges(edges, tensors.FromValue([]bool{true, false, false, false, true}), ReduceFirst)
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, false, true}, coalescedFeatures.Value())
+}
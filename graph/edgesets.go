@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// edgeKey packs an edge into an uint64 that sorts by source and then by target.
+func edgeKey(source, target int32) uint64 {
+	return uint64(uint32(source))<<32 | uint64(uint32(target))
+}
+
+// uniqueEdges holds the sorted unique edges of an edges tensor, with the index of the first occurrence of each.
+type uniqueEdges struct {
+	keys    []uint64
+	indices []int32
+}
+
+// newUniqueEdges sorts and de-duplicates the edges of the edges tensor shaped [2, numEdges]Int32.
+func newUniqueEdges(edgesT *tensors.Tensor) (*uniqueEdges, error) {
+	if edgesT == nil {
+		return nil, fmt.Errorf("edges tensor is nil")
+	}
+	if err := checkEdges(edgesT); err != nil {
+		return nil, err
+	}
+	numEdges := edgesT.Shape().Dimensions[1]
+	keys := make([]uint64, numEdges)
+	tensors.ConstFlatData(edgesT, func(flat []int32) {
+		for i := range numEdges {
+			keys[i] = edgeKey(flat[i], flat[numEdges+i])
+		}
+	})
+	order := rangeIndices(0, int32(numEdges))
+	slices.SortStableFunc(order, func(a, b int32) int { return cmp.Compare(keys[a], keys[b]) })
+	u := &uniqueEdges{}
+	for _, edgeIdx := range order {
+		key := keys[edgeIdx]
+		if len(u.keys) > 0 && u.keys[len(u.keys)-1] == key {
+			continue
+		}
+		u.keys = append(u.keys, key)
+		u.indices = append(u.indices, edgeIdx)
+	}
+	return u, nil
+}
+
+// keysToEdges converts the packed edge keys to an edges tensor shaped [2, numEdges]Int32.
+func keysToEdges(keys []uint64) *tensors.Tensor {
+	numEdges := len(keys)
+	edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	tensors.MutableFlatData(edges, func(flat []int32) {
+		for i, key := range keys {
+			flat[i] = int32(uint32(key >> 32))
+			flat[numEdges+i] = int32(uint32(key))
+		}
+	})
+	return edges
+}
+
+// mergeEdgeSets walks the sorted unique edges of a and b in order, and for each edge that passes keep, records it
+// with the index of its first occurrence in a and in b (or -1 if not present).
+func mergeEdgeSets(a, b *uniqueEdges, keep func(inA, inB bool) bool) (keys []uint64, indexA, indexB []int32) {
+	i, j := 0, 0
+	for i < len(a.keys) || j < len(b.keys) {
+		var key uint64
+		inA := i < len(a.keys) && (j >= len(b.keys) || a.keys[i] <= b.keys[j])
+		inB := j < len(b.keys) && (i >= len(a.keys) || b.keys[j] <= a.keys[i])
+		idxA, idxB := int32(-1), int32(-1)
+		if inA {
+			key, idxA = a.keys[i], a.indices[i]
+			i++
+		}
+		if inB {
+			key, idxB = b.keys[j], b.indices[j]
+			j++
+		}
+		if keep(inA, inB) {
+			keys = append(keys, key)
+			indexA = append(indexA, idxA)
+			indexB = append(indexB, idxB)
+		}
+	}
+	return
+}
+
+// edgeSetOperation implements the set operations on edges.
+func edgeSetOperation(name string, a, b *tensors.Tensor, keep func(inA, inB bool) bool) (
+	edges, indexA, indexB *tensors.Tensor, err error) {
+	uniqueA, err := newUniqueEdges(a)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: first edges: %w", name, err)
+	}
+	uniqueB, err := newUniqueEdges(b)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: second edges: %w", name, err)
+	}
+	keys, idxA, idxB := mergeEdgeSets(uniqueA, uniqueB, keep)
+	if len(keys) == 0 {
+		return nil, nil, nil, fmt.Errorf("%s: resulting edge set is empty", name)
+	}
+	return keysToEdges(keys), tensors.FromValue(idxA), tensors.FromValue(idxB), nil
+}
+
+// IntersectEdges returns the edges present in both a and b, each shaped [2, numEdges]Int32.
+//
+// Duplicate edges are considered once, and the output edges are unique and sorted by source and then by target
+// (as in SortEdgesBySource).
+//
+// It also returns the index maps indexA and indexB shaped [numOutputEdges]Int32, with the index of each output
+// edge in a and in b (the first occurrence, if duplicated), so edge features can be carried over with GatherRows.
+//
+// It returns an error if the intersection is empty, since empty tensors are not supported.
+func IntersectEdges(a, b *tensors.Tensor) (edges, indexA, indexB *tensors.Tensor, err error) {
+	return edgeSetOperation("IntersectEdges", a, b, func(inA, inB bool) bool { return inA && inB })
+}
+
+// DifferenceEdges returns the edges of a that are not present in b, each shaped [2, numEdges]Int32.
+// A typical use is removing validation edges from a training graph.
+//
+// Duplicate edges are considered once, and the output edges are unique and sorted by source and then by target
+// (as in SortEdgesBySource).
+//
+// It also returns the index map indexA shaped [numOutputEdges]Int32, with the index of each output
+// edge in a (the first occurrence, if duplicated), so edge features can be carried over with GatherRows.
+//
+// It returns an error if the difference is empty, since empty tensors are not supported.
+func DifferenceEdges(a, b *tensors.Tensor) (edges, indexA *tensors.Tensor, err error) {
+	edges, indexA, _, err = edgeSetOperation("DifferenceEdges", a, b, func(inA, inB bool) bool { return inA && !inB })
+	return
+}
+
+// SymmetricDifferenceEdges returns the edges present in only one of a or b, each shaped [2, numEdges]Int32.
+//
+// Duplicate edges are considered once, and the output edges are unique and sorted by source and then by target
+// (as in SortEdgesBySource).
+//
+// It also returns the index maps indexA and indexB shaped [numOutputEdges]Int32, with the index of each output
+// edge in a or in b (the first occurrence, if duplicated). Since each edge comes from only one of the inputs, the
+// index in the other input is -1.
+//
+// It returns an error if the symmetric difference is empty, since empty tensors are not supported.
+func SymmetricDifferenceEdges(a, b *tensors.Tensor) (edges, indexA, indexB *tensors.Tensor, err error) {
+	return edgeSetOperation("SymmetricDifferenceEdges", a, b, func(inA, inB bool) bool { return inA != inB })
+}
@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdgeSets(t *testing.T) {
+	a := tensors.FromValue([][]int32{{2, 0, 1, 0, 3}, {0, 1, 2, 1, 3}})
+	b := tensors.FromValue([][]int32{{1, 3, 0, 4}, {2, 3, 2, 0}})
+
+	edges, indexA, indexB, err := IntersectEdges(a, b)
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{1, 3}, {2, 3}}, edges.Value())
+	require.Equal(t, []int32{2, 4}, indexA.Value())
+	require.Equal(t, []int32{0, 1}, indexB.Value())
+
+	edges, indexA, err = DifferenceEdges(a, b)
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0, 2}, {1, 0}}, edges.Value())
+	require.Equal(t, []int32{1, 0}, indexA.Value())
+
+	edges, indexA, indexB, err = SymmetricDifferenceEdges(a, b)
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0, 0, 2, 4}, {1, 2, 0, 0}}, edges.Value())
+	require.Equal(t, []int32{1, -1, 0, -1}, indexA.Value())
+	require.Equal(t, []int32{-1, 2, -1, 3}, indexB.Value())
+
+	// Carrying edge features over.
+	featuresA := tensors.FromValue([]float32{20, 1, 12, 1, 33})
+	_, indexA, err = DifferenceEdges(a, b)
+	require.NoError(t, err)
+	require.Equal(t, []float32{1, 20}, GatherRows(featuresA, tensors.CopyFlatData[int32](indexA)).Value())
+
+	// Empty results and invalid inputs.
+	_, _, err = DifferenceEdges(b, a.Clone())
+	require.NoError(t, err)
+	_, _, err = DifferenceEdges(a, a)
+	require.Error(t, err)
+	_, _, _, err = IntersectEdges(a, tensors.FromValue([][]int32{{5}, {5}}))
+	require.Error(t, err)
+	_, _, _, err = SymmetricDifferenceEdges(a, tensors.FromValue([]int32{1, 2}))
+	require.Error(t, err)
+}
@@ -1,3 +1,264 @@
 // Package graph provides supporting functionality for manipulating graphs stored as feature
 // tensors and edges tensors.
 package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gomlx/exceptions"
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// Graph bundles the tensors that describe one (homogeneous) graph: its edges and the optional node and edge
+// features, positions and labels.
+//
+// All tensors are kept in local (CPU) memory by the functions in this package. Except for NumNodes and Edges,
+// all fields are optional and can be left nil.
+//
+// See New to create a Graph, and Graph.Validate to check that its contents are consistent.
+type Graph struct {
+	// NumNodes in the graph. Node indices in Edges must be in the range [0, NumNodes).
+	NumNodes int
+
+	// Edges shaped [2, numEdges]Int32, where edge_i connects source node Edges[0][i] to target node Edges[1][i].
+	Edges *tensors.Tensor
+
+	// NodeFeatures shaped [NumNodes, ...], with any dtype.
+	NodeFeatures *tensors.Tensor
+
+	// EdgeFeatures shaped [numEdges, ...], with any dtype. They are permuted along with the Edges by the
+	// methods that reorder the edges (e.g. Graph.SortEdgesBySource).
+	EdgeFeatures *tensors.Tensor
+
+	// Positions of the nodes for geometric graphs, shaped [NumNodes, dimension].
+	Positions *tensors.Tensor
+
+	// Labels for the graph, with any shape and dtype: usually either node-level labels shaped [NumNodes, ...],
+	// or graph-level labels. They are not validated.
+	Labels *tensors.Tensor
+}
+
+// New creates a Graph with the given number of nodes and edges, shaped [2, numEdges]Int32.
+// The other fields can be set directly.
+func New(numNodes int, edges *tensors.Tensor) *Graph {
+	return &Graph{
+		NumNodes: numNodes,
+		Edges:    edges,
+	}
+}
+
+// NumEdges in the graph, or 0 if Edges is nil.
+func (g *Graph) NumEdges() int {
+	if g.Edges == nil {
+		return 0
+	}
+	return g.Edges.Shape().Dimensions[1]
+}
+
+// Validate checks that the Edges tensor is valid (shape, dtype and node indices within [0, NumNodes)), and that
+// the leading dimension of the node and edge features and positions match the number of nodes and edges.
+func (g *Graph) Validate() error {
+	if g.NumNodes <= 0 {
+		return fmt.Errorf("invalid number of nodes %d in graph", g.NumNodes)
+	}
+	if g.Edges == nil {
+		return fmt.Errorf("graph has no edges tensor")
+	}
+	if err := checkEdges(g.Edges); err != nil {
+		return err
+	}
+	var err error
+	tensors.ConstFlatData(g.Edges, func(flat []int32) {
+		for i, nodeIdx := range flat {
+			if nodeIdx < 0 || int(nodeIdx) >= g.NumNodes {
+				err = fmt.Errorf("edge #%d refers to node %d, but there are only %d nodes", i%g.NumEdges(), nodeIdx, g.NumNodes)
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if err = checkLeadingDim("NodeFeatures", g.NodeFeatures, g.NumNodes); err != nil {
+		return err
+	}
+	if err = checkLeadingDim("Positions", g.Positions, g.NumNodes); err != nil {
+		return err
+	}
+	if g.Positions != nil && g.Positions.Shape().Rank() != 2 {
+		return fmt.Errorf("invalid shape for Positions: got %s, wanted [NumNodes=%d, dimension]", g.Positions.Shape(), g.NumNodes)
+	}
+	return checkLeadingDim("EdgeFeatures", g.EdgeFeatures, g.NumEdges())
+}
+
+// checkLeadingDim returns an error if t is not nil and its first axis doesn't have the given dimension.
+func checkLeadingDim(name string, t *tensors.Tensor, dim int) error {
+	if t == nil {
+		return nil
+	}
+	if t.Shape().Rank() < 1 || t.Shape().Dimensions[0] != dim {
+		return fmt.Errorf("invalid shape for %s: got %s, wanted the first axis to have dimension %d", name, t.Shape(), dim)
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the graph: all tensors are cloned.
+func (g *Graph) Clone() *Graph {
+	return &Graph{
+		NumNodes:     g.NumNodes,
+		Edges:        cloneTensor(g.Edges),
+		NodeFeatures: cloneTensor(g.NodeFeatures),
+		EdgeFeatures: cloneTensor(g.EdgeFeatures),
+		Positions:    cloneTensor(g.Positions),
+		Labels:       cloneTensor(g.Labels),
+	}
+}
+
+func cloneTensor(t *tensors.Tensor) *tensors.Tensor {
+	if t == nil {
+		return nil
+	}
+	return t.Clone()
+}
+
+// Sources returns a copy of the source node indices of the edges.
+func (g *Graph) Sources() []int32 {
+	sources := make([]int32, g.NumEdges())
+	if len(sources) > 0 {
+		tensors.ConstFlatData(g.Edges, func(flat []int32) {
+			copy(sources, flat[:len(sources)])
+		})
+	}
+	return sources
+}
+
+// Targets returns a copy of the target node indices of the edges.
+func (g *Graph) Targets() []int32 {
+	targets := make([]int32, g.NumEdges())
+	if len(targets) > 0 {
+		tensors.ConstFlatData(g.Edges, func(flat []int32) {
+			copy(targets, flat[len(targets):])
+		})
+	}
+	return targets
+}
+
+// SortEdgesBySource sorts the edges by source node index and then by target node index, in-place,
+// and permutes the EdgeFeatures accordingly.
+//
+// See also the function SortEdgesBySource, that operates directly on the edges tensor.
+func (g *Graph) SortEdgesBySource() error {
+	if err := checkEdges(g.Edges); err != nil {
+		return err
+	}
+	if err := checkLeadingDim("EdgeFeatures", g.EdgeFeatures, g.NumEdges()); err != nil {
+		return err
+	}
+	if g.EdgeFeatures == nil {
+		return SortEdgesBySource(g.Edges)
+	}
+	numEdges := g.NumEdges()
+	permutation := make([]int32, numEdges)
+	tensors.MutableFlatData(g.Edges, func(flat []int32) {
+		sources, targets := flat[:numEdges], flat[numEdges:]
+		for i := range permutation {
+			permutation[i] = int32(i)
+		}
+		sort.SliceStable(permutation, func(i, j int) bool {
+			edgeI, edgeJ := permutation[i], permutation[j]
+			if sources[edgeI] != sources[edgeJ] {
+				return sources[edgeI] < sources[edgeJ]
+			}
+			return targets[edgeI] < targets[edgeJ]
+		})
+		sortedSources := make([]int32, numEdges)
+		sortedTargets := make([]int32, numEdges)
+		for i, edgeIdx := range permutation {
+			sortedSources[i] = sources[edgeIdx]
+			sortedTargets[i] = targets[edgeIdx]
+		}
+		copy(sources, sortedSources)
+		copy(targets, sortedTargets)
+	})
+	g.EdgeFeatures = GatherRows(g.EdgeFeatures, permutation)
+	return nil
+}
+
+// UnionEdges merges the given edges tensors into the graph edges, removing duplicates. See the function UnionEdges.
+//
+// Since the merged edges can't carry over features, it returns an error if the graph has EdgeFeatures.
+func (g *Graph) UnionEdges(edges ...*tensors.Tensor) error {
+	if g.EdgeFeatures != nil {
+		return fmt.Errorf("can't union edges of a graph with EdgeFeatures, since the new edges have no features")
+	}
+	union, err := UnionEdges(append([]*tensors.Tensor{g.Edges}, edges...)...)
+	if err != nil {
+		return err
+	}
+	g.Edges = union
+	return nil
+}
+
+// GatherRows returns a new tensor with the rows (the slices along the first axis) of t selected by indices,
+// that is, output[i] = t[indices[i]]. It works with tensors of any dtype and rank >= 1.
+//
+// It is commonly used to carry node or edge features over when nodes or edges are selected or reordered.
+// It panics if an index is out of range or if len(indices) == 0.
+func GatherRows(t *tensors.Tensor, indices []int32) *tensors.Tensor {
+	dims := t.Shape().Clone().Dimensions
+	numRows := dims[0]
+	dims[0] = len(indices)
+	output := tensors.FromShape(shapes.Make(t.DType(), dims...))
+	rowSize := int(t.Shape().Memory()) / max(numRows, 1)
+	t.ConstBytes(func(data []byte) {
+		output.MutableBytes(func(outputData []byte) {
+			for i, rowIdx := range indices {
+				if rowIdx < 0 || int(rowIdx) >= numRows {
+					exceptions.Panicf("GatherRows: index %d out of range for tensor shaped %s", rowIdx, t.Shape())
+				}
+				copy(outputData[i*rowSize:(i+1)*rowSize], data[int(rowIdx)*rowSize:int(rowIdx+1)*rowSize])
+			}
+		})
+	})
+	return output
+}
+
+// ConcatenateRows returns a new tensor with the concatenation of the given tensors along the first axis.
+// The tensors must all have the same dtype and the same dimensions, except for the first axis.
+// It works with tensors of any dtype and rank >= 1.
+func ConcatenateRows(ts ...*tensors.Tensor) (*tensors.Tensor, error) {
+	if len(ts) == 0 {
+		return nil, fmt.Errorf("no tensors to concatenate")
+	}
+	first := ts[0].Shape()
+	if first.Rank() < 1 {
+		return nil, fmt.Errorf("can't concatenate scalar tensors")
+	}
+	var numRows int
+	for i, t := range ts {
+		shape := t.Shape()
+		if shape.DType != first.DType || shape.Rank() != first.Rank() {
+			return nil, fmt.Errorf("can't concatenate tensor #%d shaped %s with tensor #0 shaped %s", i, shape, first)
+		}
+		for axis := 1; axis < shape.Rank(); axis++ {
+			if shape.Dimensions[axis] != first.Dimensions[axis] {
+				return nil, fmt.Errorf("can't concatenate tensor #%d shaped %s with tensor #0 shaped %s", i, shape, first)
+			}
+		}
+		numRows += shape.Dimensions[0]
+	}
+	dims := first.Clone().Dimensions
+	dims[0] = numRows
+	output := tensors.FromShape(shapes.Make(first.DType, dims...))
+	output.MutableBytes(func(outputData []byte) {
+		var pos int
+		for _, t := range ts {
+			t.ConstBytes(func(data []byte) {
+				pos += copy(outputData[pos:], data)
+			})
+		}
+	})
+	return output, nil
+}
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	g := New(3, tensors.FromValue([][]int32{{2, 0, 1, 0}, {0, 2, 2, 1}}))
+	g.NodeFeatures = tensors.FromValue([][]float32{{0}, {1}, {2}})
+	g.EdgeFeatures = tensors.FromValue([][]float32{{20, 0.2}, {2, 0.02}, {12, 0.12}, {1, 0.01}})
+	require.NoError(t, g.Validate())
+	require.Equal(t, 4, g.NumEdges())
+	require.Equal(t, []int32{2, 0, 1, 0}, g.Sources())
+	require.Equal(t, []int32{0, 2, 2, 1}, g.Targets())
+
+	// Clone is a deep copy.
+	clone := g.Clone()
+	require.NoError(t, clone.SortEdgesBySource())
+	require.Equal(t, [][]int32{{0, 0, 1, 2}, {1, 2, 2, 0}}, clone.Edges.Value())
+	require.Equal(t, [][]float32{{1, 0.01}, {2, 0.02}, {12, 0.12}, {20, 0.2}}, clone.EdgeFeatures.Value())
+	require.Equal(t, []int32{2, 0, 1, 0}, g.Sources())
+
+	// Union of edges is not possible with edge features.
+	require.Error(t, g.UnionEdges(tensors.FromValue([][]int32{{1}, {0}})))
+	g.EdgeFeatures = nil
+	require.NoError(t, g.UnionEdges(tensors.FromValue([][]int32{{1, 2}, {0, 0}})))
+	require.NoError(t, g.SortEdgesBySource())
+	require.Equal(t, [][]int32{{0, 0, 1, 1, 2}, {1, 2, 0, 2, 0}}, g.Edges.Value())
+
+	// Validation errors.
+	g.NumNodes = 2
+	require.Error(t, g.Validate())
+	g.NumNodes = 3
+	g.EdgeFeatures = tensors.FromValue([]float32{1, 2})
+	require.Error(t, g.Validate())
+	g.EdgeFeatures = nil
+	g.Positions = tensors.FromValue([][]float32{{0, 0}, {1, 1}})
+	require.Error(t, g.Validate())
+}
+
+func TestGatherRows(t *testing.T) {
+	values := tensors.FromValue([][]int64{{0, 1}, {2, 3}, {4, 5}})
+	require.Equal(t, [][]int64{{4, 5}, {0, 1}, {4, 5}}, GatherRows(values, []int32{2, 0, 2}).Value())
+	flags := tensors.FromValue([]bool{true, false})
+	require.Equal(t, []bool{false, true}, GatherRows(flags, []int32{1, 0}).Value())
+	require.Panics(t, func() { GatherRows(values, []int32{3}) })
+}
@@ -0,0 +1,393 @@
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// NodeSet is a set of nodes of the same type in a HeteroGraph.
+type NodeSet struct {
+	// NumNodes in the set.
+	NumNodes int
+
+	// Features shaped [NumNodes, ...], with any dtype. Optional.
+	Features *tensors.Tensor
+
+	// Labels shaped [NumNodes, ...], with any dtype. Optional.
+	Labels *tensors.Tensor
+}
+
+// EdgeType identifies an edge set in a HeteroGraph by the types of its source and target nodes, and the
+// relation they represent. E.g.: {"user", "buys", "item"}.
+type EdgeType struct {
+	Source, Relation, Target string
+}
+
+// String implements fmt.Stringer.
+func (t EdgeType) String() string {
+	return fmt.Sprintf("(%s, %s, %s)", t.Source, t.Relation, t.Target)
+}
+
+// EdgeSet is a set of edges of the same EdgeType in a HeteroGraph.
+type EdgeSet struct {
+	// Edges shaped [2, numEdges]Int32, where edge_i connects node Edges[0][i] of the source node set to node
+	// Edges[1][i] of the target node set. It is nil for a relation without edges.
+	Edges *tensors.Tensor
+
+	// Features shaped [numEdges, ...], with any dtype. Optional, and must be nil if Edges is nil.
+	Features *tensors.Tensor
+}
+
+// NumEdges in the set, or 0 if Edges is nil.
+func (s *EdgeSet) NumEdges() int {
+	if s.Edges == nil {
+		return 0
+	}
+	return s.Edges.Shape().Dimensions[1]
+}
+
+// HeteroGraph is a heterogeneous graph, with multiple named node sets and multiple edge sets, each
+// connecting a source node set to a target node set with a named relation (in the spirit of TF-GNN's GraphTensor).
+//
+// Node indices in each edge set are local to the corresponding source and target node sets.
+//
+// See NewHeteroGraph to create one, and HeteroGraph.ToHomogeneous and FromHomogeneous to convert to and from
+// a homogeneous Graph.
+type HeteroGraph struct {
+	// NodeSets indexed by their node type name.
+	NodeSets map[string]*NodeSet
+
+	// EdgeSets indexed by their EdgeType.
+	EdgeSets map[EdgeType]*EdgeSet
+}
+
+// NewHeteroGraph creates an empty HeteroGraph. Use AddNodeSet and AddEdgeSet to populate it.
+func NewHeteroGraph() *HeteroGraph {
+	return &HeteroGraph{
+		NodeSets: make(map[string]*NodeSet),
+		EdgeSets: make(map[EdgeType]*EdgeSet),
+	}
+}
+
+// AddNodeSet adds (or replaces) the node set with the given node type name, and returns it, so that the
+// optional fields can be set.
+func (h *HeteroGraph) AddNodeSet(nodeType string, numNodes int, features *tensors.Tensor) *NodeSet {
+	nodeSet := &NodeSet{NumNodes: numNodes, Features: features}
+	h.NodeSets[nodeType] = nodeSet
+	return nodeSet
+}
+
+// AddEdgeSet adds (or replaces) the edge set with the given type, and returns it, so that the
+// optional fields can be set.
+func (h *HeteroGraph) AddEdgeSet(edgeType EdgeType, edges, features *tensors.Tensor) *EdgeSet {
+	edgeSet := &EdgeSet{Edges: edges, Features: features}
+	h.EdgeSets[edgeType] = edgeSet
+	return edgeSet
+}
+
+// NodeTypes returns the names of the node sets, sorted.
+func (h *HeteroGraph) NodeTypes() []string {
+	nodeTypes := make([]string, 0, len(h.NodeSets))
+	for nodeType := range h.NodeSets {
+		nodeTypes = append(nodeTypes, nodeType)
+	}
+	slices.Sort(nodeTypes)
+	return nodeTypes
+}
+
+// EdgeTypes returns the types of the edge sets, sorted by source, relation and then target.
+func (h *HeteroGraph) EdgeTypes() []EdgeType {
+	edgeTypes := make([]EdgeType, 0, len(h.EdgeSets))
+	for edgeType := range h.EdgeSets {
+		edgeTypes = append(edgeTypes, edgeType)
+	}
+	slices.SortFunc(edgeTypes, compareEdgeTypes)
+	return edgeTypes
+}
+
+func compareEdgeTypes(a, b EdgeType) int {
+	if c := cmp.Compare(a.Source, b.Source); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Relation, b.Relation); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Target, b.Target)
+}
+
+// Validate checks that all edge sets connect existing node sets, that their edges are valid (shape, dtype and
+// node indices within the number of nodes of the source and target node sets), and that the leading dimension
+// of the features and labels match the number of nodes or edges of each set.
+func (h *HeteroGraph) Validate() error {
+	for _, nodeType := range h.NodeTypes() {
+		nodeSet := h.NodeSets[nodeType]
+		if nodeSet.NumNodes < 0 {
+			return fmt.Errorf("invalid number of nodes %d for node set %q", nodeSet.NumNodes, nodeType)
+		}
+		if err := checkLeadingDim(fmt.Sprintf("features of node set %q", nodeType), nodeSet.Features, nodeSet.NumNodes); err != nil {
+			return err
+		}
+		if err := checkLeadingDim(fmt.Sprintf("labels of node set %q", nodeType), nodeSet.Labels, nodeSet.NumNodes); err != nil {
+			return err
+		}
+	}
+	for _, edgeType := range h.EdgeTypes() {
+		edgeSet := h.EdgeSets[edgeType]
+		sourceSet, found := h.NodeSets[edgeType.Source]
+		if !found {
+			return fmt.Errorf("edge set %s refers to unknown source node set %q", edgeType, edgeType.Source)
+		}
+		targetSet, found := h.NodeSets[edgeType.Target]
+		if !found {
+			return fmt.Errorf("edge set %s refers to unknown target node set %q", edgeType, edgeType.Target)
+		}
+		if edgeSet.Edges == nil {
+			if edgeSet.Features != nil {
+				return fmt.Errorf("edge set %s has features but no edges tensor", edgeType)
+			}
+			continue
+		}
+		if err := checkEdges(edgeSet.Edges); err != nil {
+			return fmt.Errorf("edge set %s: %w", edgeType, err)
+		}
+		numEdges := edgeSet.NumEdges()
+		var err error
+		tensors.ConstFlatData(edgeSet.Edges, func(flat []int32) {
+			for i, nodeIdx := range flat {
+				numNodes := sourceSet.NumNodes
+				if i >= numEdges {
+					numNodes = targetSet.NumNodes
+				}
+				if nodeIdx < 0 || int(nodeIdx) >= numNodes {
+					err = fmt.Errorf("edge set %s: edge #%d refers to node %d, but its node set has only %d nodes",
+						edgeType, i%numEdges, nodeIdx, numNodes)
+					return
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if err = checkLeadingDim(fmt.Sprintf("features of edge set %s", edgeType), edgeSet.Features, numEdges); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToHomogeneous converts the heterogeneous graph to a homogeneous Graph, along with the type of each node and edge.
+//
+// The nodes of each node set are concatenated in the order of HeteroGraph.NodeTypes, and the edges of each edge set
+// are concatenated in the order of HeteroGraph.EdgeTypes, with their node indices offset accordingly.
+// Node features (and labels) are concatenated if all node sets have them with the same dtype and inner dimensions,
+// and they are left nil if no node set has them -- otherwise it is an error. The same for edge features.
+//
+// It returns the graph, plus nodeType shaped [NumNodes]Int32 with the index (in HeteroGraph.NodeTypes) of the
+// type of each node, and edgeType shaped [numEdges]Int32 with the index (in HeteroGraph.EdgeTypes) of the type of
+// each edge.
+func (h *HeteroGraph) ToHomogeneous() (g *Graph, nodeType, edgeType *tensors.Tensor, err error) {
+	if err = h.Validate(); err != nil {
+		return nil, nil, nil, err
+	}
+	nodeTypes := h.NodeTypes()
+	edgeTypes := h.EdgeTypes()
+
+	offsets := make(map[string]int32, len(nodeTypes))
+	var numNodes int
+	nodeTypeIDs := make([]int32, 0)
+	var nodeFeatures, nodeLabels []*tensors.Tensor
+	for typeID, name := range nodeTypes {
+		nodeSet := h.NodeSets[name]
+		offsets[name] = int32(numNodes)
+		numNodes += nodeSet.NumNodes
+		for range nodeSet.NumNodes {
+			nodeTypeIDs = append(nodeTypeIDs, int32(typeID))
+		}
+		if nodeSet.NumNodes > 0 {
+			nodeFeatures = append(nodeFeatures, nodeSet.Features)
+			nodeLabels = append(nodeLabels, nodeSet.Labels)
+		}
+	}
+	if numNodes == 0 {
+		return nil, nil, nil, fmt.Errorf("heterogeneous graph has no nodes")
+	}
+
+	var numEdges int
+	var edgeFeatures []*tensors.Tensor
+	for _, t := range edgeTypes {
+		numEdges += h.EdgeSets[t].NumEdges()
+		if h.EdgeSets[t].NumEdges() > 0 {
+			edgeFeatures = append(edgeFeatures, h.EdgeSets[t].Features)
+		}
+	}
+	if numEdges == 0 {
+		return nil, nil, nil, fmt.Errorf("heterogeneous graph has no edges")
+	}
+	edgeTypeIDs := make([]int32, 0, numEdges)
+	edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	tensors.MutableFlatData(edges, func(flatEdges []int32) {
+		var pos int
+		for typeID, t := range edgeTypes {
+			edgeSet := h.EdgeSets[t]
+			setNumEdges := edgeSet.NumEdges()
+			if setNumEdges == 0 {
+				continue
+			}
+			sourceOffset, targetOffset := offsets[t.Source], offsets[t.Target]
+			tensors.ConstFlatData(edgeSet.Edges, func(flat []int32) {
+				for i := range setNumEdges {
+					flatEdges[pos+i] = flat[i] + sourceOffset
+					flatEdges[numEdges+pos+i] = flat[setNumEdges+i] + targetOffset
+					edgeTypeIDs = append(edgeTypeIDs, int32(typeID))
+				}
+			})
+			pos += setNumEdges
+		}
+	})
+
+	g = New(numNodes, edges)
+	if g.NodeFeatures, err = concatenateOptional("node features", nodeFeatures); err != nil {
+		return nil, nil, nil, err
+	}
+	if g.Labels, err = concatenateOptional("node labels", nodeLabels); err != nil {
+		return nil, nil, nil, err
+	}
+	if g.EdgeFeatures, err = concatenateOptional("edge features", edgeFeatures); err != nil {
+		return nil, nil, nil, err
+	}
+	return g, tensors.FromValue(nodeTypeIDs), tensors.FromValue(edgeTypeIDs), nil
+}
+
+// concatenateOptional concatenates the tensors along the first axis if they are all set, returns nil if none are set,
+// and returns an error if only some are set.
+func concatenateOptional(name string, ts []*tensors.Tensor) (*tensors.Tensor, error) {
+	numSet := 0
+	for _, t := range ts {
+		if t != nil {
+			numSet++
+		}
+	}
+	if numSet == 0 {
+		return nil, nil
+	}
+	if numSet != len(ts) {
+		return nil, fmt.Errorf("%s are set only for some of the sets (%d out of %d), they must be set for all or none",
+			name, numSet, len(ts))
+	}
+	t, err := ConcatenateRows(ts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to concatenate %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// FromHomogeneous converts a homogeneous Graph to a HeteroGraph, given the type of each node and edge.
+// It is the inverse of HeteroGraph.ToHomogeneous.
+//
+// Args:
+//   - g: the homogeneous graph. Its NodeFeatures and Labels (if set, and if shaped [NumNodes, ...]) are split
+//     into the node sets, and its EdgeFeatures into the edge sets. Positions are ignored.
+//   - nodeType: shaped [NumNodes]Int32, the index in nodeTypes of the type of each node.
+//   - edgeType: shaped [numEdges]Int32, the index in edgeTypes of the type of each edge.
+//   - nodeTypes: the names of the node types.
+//   - edgeTypes: the edge types. The types of the source and target nodes of each edge must match those of its
+//     edge type.
+//
+// The nodes and edges of each set keep their relative order. Node sets with no nodes are created with NumNodes 0,
+// and edge sets with no edges are omitted.
+func FromHomogeneous(g *Graph, nodeType, edgeType *tensors.Tensor, nodeTypes []string, edgeTypes []EdgeType) (*HeteroGraph, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkTypeIDs("nodeType", nodeType, g.NumNodes, len(nodeTypes)); err != nil {
+		return nil, err
+	}
+	if err := checkTypeIDs("edgeType", edgeType, g.NumEdges(), len(edgeTypes)); err != nil {
+		return nil, err
+	}
+	nodeTypeIDs := tensors.CopyFlatData[int32](nodeType)
+	edgeTypeIDs := tensors.CopyFlatData[int32](edgeType)
+
+	// Local index of each node within its node set.
+	localIndices := make([]int32, g.NumNodes)
+	nodesPerType := make([][]int32, len(nodeTypes))
+	for nodeIdx, typeID := range nodeTypeIDs {
+		localIndices[nodeIdx] = int32(len(nodesPerType[typeID]))
+		nodesPerType[typeID] = append(nodesPerType[typeID], int32(nodeIdx))
+	}
+	nodeLabels := g.Labels
+	if nodeLabels != nil && checkLeadingDim("Labels", nodeLabels, g.NumNodes) != nil {
+		// Graph-level labels are not split.
+		nodeLabels = nil
+	}
+	h := NewHeteroGraph()
+	for typeID, name := range nodeTypes {
+		nodes := nodesPerType[typeID]
+		nodeSet := h.AddNodeSet(name, len(nodes), nil)
+		if len(nodes) == 0 {
+			continue
+		}
+		if g.NodeFeatures != nil {
+			nodeSet.Features = GatherRows(g.NodeFeatures, nodes)
+		}
+		if nodeLabels != nil {
+			nodeSet.Labels = GatherRows(nodeLabels, nodes)
+		}
+	}
+
+	sources, targets := g.Sources(), g.Targets()
+	edgesPerType := make([][]int32, len(edgeTypes))
+	for edgeIdx, typeID := range edgeTypeIDs {
+		t := edgeTypes[typeID]
+		sourceType, targetType := nodeTypes[nodeTypeIDs[sources[edgeIdx]]], nodeTypes[nodeTypeIDs[targets[edgeIdx]]]
+		if sourceType != t.Source || targetType != t.Target {
+			return nil, fmt.Errorf("edge #%d of type %s connects nodes of types %q and %q", edgeIdx, t, sourceType, targetType)
+		}
+		edgesPerType[typeID] = append(edgesPerType[typeID], int32(edgeIdx))
+	}
+	for typeID, t := range edgeTypes {
+		edgeIndices := edgesPerType[typeID]
+		numEdges := len(edgeIndices)
+		if numEdges == 0 {
+			continue
+		}
+		edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+		tensors.MutableFlatData(edges, func(flat []int32) {
+			for i, edgeIdx := range edgeIndices {
+				flat[i] = localIndices[sources[edgeIdx]]
+				flat[numEdges+i] = localIndices[targets[edgeIdx]]
+			}
+		})
+		edgeSet := h.AddEdgeSet(t, edges, nil)
+		if g.EdgeFeatures != nil {
+			edgeSet.Features = GatherRows(g.EdgeFeatures, edgeIndices)
+		}
+	}
+	return h, nil
+}
+
+// checkTypeIDs checks that typeIDs is shaped [size]Int32 with values in [0, numTypes).
+func checkTypeIDs(name string, typeIDs *tensors.Tensor, size, numTypes int) error {
+	if typeIDs == nil || typeIDs.DType() != dtypes.Int32 || typeIDs.Shape().Rank() != 1 || typeIDs.Shape().Dimensions[0] != size {
+		var shape any = "nil"
+		if typeIDs != nil {
+			shape = typeIDs.Shape()
+		}
+		return fmt.Errorf("invalid %s tensor: got %s, wanted [%d]Int32", name, shape, size)
+	}
+	var err error
+	tensors.ConstFlatData(typeIDs, func(flat []int32) {
+		for i, typeID := range flat {
+			if typeID < 0 || int(typeID) >= numTypes {
+				err = fmt.Errorf("%s[%d]=%d is out of range, there are only %d types", name, i, typeID, numTypes)
+				return
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeteroGraph(t *testing.T) {
+	h := NewHeteroGraph()
+	h.AddNodeSet("user", 2, tensors.FromValue([][]float32{{0}, {1}}))
+	h.AddNodeSet("item", 3, tensors.FromValue([][]float32{{10}, {11}, {12}}))
+	buys := EdgeType{"user", "buys", "item"}
+	follows := EdgeType{"user", "follows", "user"}
+	h.AddEdgeSet(buys, tensors.FromValue([][]int32{{0, 1, 1}, {2, 0, 1}}), tensors.FromValue([]float32{0.1, 0.2, 0.3}))
+	h.AddEdgeSet(follows, tensors.FromValue([][]int32{{1}, {0}}), tensors.FromValue([]float32{0.4}))
+	require.NoError(t, h.Validate())
+	require.Equal(t, []string{"item", "user"}, h.NodeTypes())
+	require.Equal(t, []EdgeType{buys, follows}, h.EdgeTypes())
+	require.Equal(t, "(user, buys, item)", buys.String())
+
+	g, nodeType, edgeType, err := h.ToHomogeneous()
+	require.NoError(t, err)
+	require.NoError(t, g.Validate())
+	require.Equal(t, 5, g.NumNodes)
+	// Items are nodes 0-2, users are nodes 3-4.
+	require.Equal(t, []int32{0, 0, 0, 1, 1}, nodeType.Value())
+	require.Equal(t, [][]float32{{10}, {11}, {12}, {0}, {1}}, g.NodeFeatures.Value())
+	require.Equal(t, [][]int32{{3, 4, 4, 4}, {2, 0, 1, 3}}, g.Edges.Value())
+	require.Equal(t, []int32{0, 0, 0, 1}, edgeType.Value())
+	require.Equal(t, []float32{0.1, 0.2, 0.3, 0.4}, g.EdgeFeatures.Value())
+
+	// Back to heterogeneous.
+	h2, err := FromHomogeneous(g, nodeType, edgeType, h.NodeTypes(), h.EdgeTypes())
+	require.NoError(t, err)
+	require.NoError(t, h2.Validate())
+	for _, name := range h.NodeTypes() {
+		require.Equal(t, h.NodeSets[name].NumNodes, h2.NodeSets[name].NumNodes)
+		require.Equal(t, h.NodeSets[name].Features.Value(), h2.NodeSets[name].Features.Value())
+	}
+	for _, edgeType := range h.EdgeTypes() {
+		require.Equal(t, h.EdgeSets[edgeType].Edges.Value(), h2.EdgeSets[edgeType].Edges.Value())
+		require.Equal(t, h.EdgeSets[edgeType].Features.Value(), h2.EdgeSets[edgeType].Features.Value())
+	}
+
+	// Mismatched edge type.
+	_, err = FromHomogeneous(g, nodeType, tensors.FromValue([]int32{1, 0, 0, 1}), h.NodeTypes(), h.EdgeTypes())
+	require.Error(t, err)
+
+	// Relations without edges are skipped, and don't need edge features.
+	views := EdgeType{"user", "views", "item"}
+	h.AddEdgeSet(views, nil, nil)
+	g, _, edgeType, err = h.ToHomogeneous()
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{3, 4, 4, 4}, {2, 0, 1, 3}}, g.Edges.Value())
+	require.Equal(t, []int32{0, 0, 0, 1}, edgeType.Value())
+	require.Equal(t, []float32{0.1, 0.2, 0.3, 0.4}, g.EdgeFeatures.Value())
+	h.AddEdgeSet(views, nil, tensors.FromValue([]float32{0.5}))
+	require.Error(t, h.Validate())
+	delete(h.EdgeSets, views)
+
+	// Validation errors.
+	h.AddEdgeSet(EdgeType{"user", "rates", "movie"}, tensors.FromValue([][]int32{{0}, {0}}), nil)
+	require.Error(t, h.Validate())
+	delete(h.EdgeSets, EdgeType{"user", "rates", "movie"})
+	h.AddEdgeSet(follows, tensors.FromValue([][]int32{{1}, {2}}), nil)
+	require.Error(t, h.Validate())
+	h.AddEdgeSet(follows, tensors.FromValue([][]int32{{1}, {0}}), nil)
+	require.NoError(t, h.Validate())
+	// Edge features only set for some edge sets.
+	_, _, _, err = h.ToHomogeneous()
+	require.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// NegativeSamplingMethod defines how NegativeSampling draws the negative edges.
+type NegativeSamplingMethod int
+
+const (
+	// UniformNegatives draws the source and target nodes uniformly.
+	UniformNegatives NegativeSamplingMethod = iota
+
+	// DegreeNegatives draws the source nodes proportionally to their out-degree, and the target nodes proportionally
+	// to their in-degree, so the negatives have the same node distribution as the positive edges. Nodes without
+	// edges are never drawn.
+	DegreeNegatives
+
+	// StructuredNegatives corrupts the target of the positive edges: negative i has the source of the positive edge
+	// i % numEdges, and a uniformly drawn target, as PyG's structured_negative_sampling.
+	StructuredNegatives
+)
+
+// String implements fmt.Stringer.
+func (m NegativeSamplingMethod) String() string {
+	switch m {
+	case UniformNegatives:
+		return "UniformNegatives"
+	case DegreeNegatives:
+		return "DegreeNegatives"
+	case StructuredNegatives:
+		return "StructuredNegatives"
+	default:
+		return fmt.Sprintf("NegativeSamplingMethod(%d)", int(m))
+	}
+}
+
+// NegativeSamplingConfig is created with NegativeSampling and once fully configured, can be executed
+// with Done.
+type NegativeSamplingConfig struct {
+	edges                  *tensors.Tensor
+	numNodes, numNegatives int
+	method                 NegativeSamplingMethod
+	numTargets             int
+	bipartite, allowLoops  bool
+	seed                   uint64
+	maxAttemptsPerNegative int
+}
+
+// NegativeSampling draws edges that are not in the graph, typically used as negative examples to train link
+// prediction. Existing edges are never returned, and, for non-bipartite graphs, neither are self-loops
+// (see AllowSelfLoops). The negatives may contain duplicates.
+//
+// It uses rejection sampling, so it is efficient for sparse graphs, and it returns an error if it can't find enough
+// negatives (e.g. for very dense graphs).
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - edges: the positive edges, shaped [2, numEdges]Int32.
+//   - numNodes: number of nodes of the graph (or number of source nodes for bipartite graphs, see Bipartite).
+//   - numNegatives: number of negative edges to draw.
+//   - method: how to draw the negative edges, see NegativeSamplingMethod.
+//
+// It returns a configuration that can be optionally configured. Call NegativeSamplingConfig.Done to perform
+// the operation.
+func NegativeSampling(edges *tensors.Tensor, numNodes, numNegatives int, method NegativeSamplingMethod) *NegativeSamplingConfig {
+	return &NegativeSamplingConfig{
+		edges:                  edges,
+		numNodes:               numNodes,
+		numNegatives:           numNegatives,
+		method:                 method,
+		numTargets:             numNodes,
+		maxAttemptsPerNegative: 100,
+	}
+}
+
+// Bipartite configures the graph as bipartite: the source nodes are in [0, numNodes) and the target nodes are in a
+// separate set [0, numTargets). Self-loops are not excluded in bipartite graphs.
+func (c *NegativeSamplingConfig) Bipartite(numTargets int) *NegativeSamplingConfig {
+	c.bipartite = true
+	c.numTargets = numTargets
+	return c
+}
+
+// AllowSelfLoops allows self-loops (that are not in the graph) to be drawn as negatives.
+func (c *NegativeSamplingConfig) AllowSelfLoops() *NegativeSamplingConfig {
+	c.allowLoops = true
+	return c
+}
+
+// Seed sets the seed of the random number generator. Default is 0.
+func (c *NegativeSamplingConfig) Seed(seed uint64) *NegativeSamplingConfig {
+	c.seed = seed
+	return c
+}
+
+// MaxAttemptsPerNegative sets how many random edges are tried for each negative before giving up with an error.
+// Default is 100.
+func (c *NegativeSamplingConfig) MaxAttemptsPerNegative(maxAttempts int) *NegativeSamplingConfig {
+	c.maxAttemptsPerNegative = maxAttempts
+	return c
+}
+
+// Done draws the negative edges as configured.
+//
+// It returns a tensor shaped [2, numNegatives]Int32, with the source and target nodes of the negative edges.
+func (c *NegativeSamplingConfig) Done() (*tensors.Tensor, error) {
+	if err := checkEdges(c.edges); err != nil {
+		return nil, err
+	}
+	if c.numNodes <= 0 || c.numTargets <= 0 || c.numNegatives <= 0 || c.maxAttemptsPerNegative <= 0 {
+		return nil, fmt.Errorf("numNodes (%d), numTargets (%d), numNegatives (%d) and maxAttemptsPerNegative (%d) "+
+			"must be positive", c.numNodes, c.numTargets, c.numNegatives, c.maxAttemptsPerNegative)
+	}
+	if c.method < UniformNegatives || c.method > StructuredNegatives {
+		return nil, fmt.Errorf("invalid negative sampling method %s", c.method)
+	}
+	numEdges := c.edges.Shape().Dimensions[1]
+	var sources, targets []int32
+	var err error
+	tensors.ConstFlatData(c.edges, func(flat []int32) {
+		sources, targets = flat[:numEdges:numEdges], flat[numEdges:]
+		for i := range numEdges {
+			if sources[i] < 0 || int(sources[i]) >= c.numNodes || targets[i] < 0 || int(targets[i]) >= c.numTargets {
+				err = fmt.Errorf("edge #%d (%d->%d) out of range for %d source and %d target nodes",
+					i, sources[i], targets[i], c.numNodes, c.numTargets)
+				return
+			}
+		}
+		sources, targets = slices.Clone(sources), slices.Clone(targets)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sorted keys of the existing edges, for the exclusion test.
+	existing := make([]uint64, numEdges)
+	for i := range numEdges {
+		existing[i] = edgeKey(sources[i], targets[i])
+	}
+	radixSortKeys(existing)
+	existing = slices.Compact(existing)
+	isValid := func(source, target int32) bool {
+		if source == target && !c.bipartite && !c.allowLoops {
+			return false
+		}
+		_, found := slices.BinarySearch(existing, edgeKey(source, target))
+		return !found
+	}
+
+	rng := rand.New(rand.NewPCG(c.seed, 0))
+	negSources := make([]int32, c.numNegatives)
+	negTargets := make([]int32, c.numNegatives)
+	for i := range c.numNegatives {
+		found := false
+		for range c.maxAttemptsPerNegative {
+			var source, target int32
+			switch c.method {
+			case UniformNegatives:
+				source, target = int32(rng.IntN(c.numNodes)), int32(rng.IntN(c.numTargets))
+			case DegreeNegatives:
+				source, target = sources[rng.IntN(numEdges)], targets[rng.IntN(numEdges)]
+			case StructuredNegatives:
+				source, target = sources[i%numEdges], int32(rng.IntN(c.numTargets))
+			}
+			if isValid(source, target) {
+				negSources[i], negTargets[i] = source, target
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("failed to find negative edge #%d after %d attempts, the graph may be too dense",
+				i, c.maxAttemptsPerNegative)
+		}
+	}
+	return edgesFromSlices(negSources, negTargets), nil
+}
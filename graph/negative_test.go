@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegativeSampling(t *testing.T) {
+	const numNodes = 50
+	rng := rand.New(rand.NewPCG(42, 0))
+	edges := randomEdges(rng, 500, numNodes)
+	sources, targets := New(numNodes, edges).Sources(), New(numNodes, edges).Targets()
+	positive := make(map[[2]int32]bool)
+	for i := range sources {
+		positive[[2]int32{sources[i], targets[i]}] = true
+	}
+
+	for _, method := range []NegativeSamplingMethod{UniformNegatives, DegreeNegatives, StructuredNegatives} {
+		negativesT, err := NegativeSampling(edges, numNodes, 1000, method).Seed(1).Done()
+		require.NoError(t, err, "method=%s", method)
+		require.Equal(t, []int{2, 1000}, negativesT.Shape().Dimensions)
+		negatives := negativesT.Value().([][]int32)
+		for i := range negatives[0] {
+			source, target := negatives[0][i], negatives[1][i]
+			require.False(t, positive[[2]int32{source, target}], "method=%s: %d->%d is a positive edge", method, source, target)
+			require.NotEqual(t, source, target, "method=%s", method)
+			if method == StructuredNegatives {
+				require.Equal(t, sources[i%len(sources)], source)
+			}
+		}
+
+		// Reproducible by seed.
+		again, err := NegativeSampling(edges, numNodes, 1000, method).Seed(1).Done()
+		require.NoError(t, err)
+		require.Equal(t, negatives, again.Value())
+	}
+
+	// Degree-weighted negatives only use nodes with edges.
+	edges = tensors.FromValue([][]int32{{0, 1}, {1, 2}})
+	negativesT, err := NegativeSampling(edges, 10, 50, DegreeNegatives).Done()
+	require.NoError(t, err)
+	negatives := negativesT.Value().([][]int32)
+	for i := range negatives[0] {
+		require.Contains(t, []int32{0, 1}, negatives[0][i])
+		require.Contains(t, []int32{1, 2}, negatives[1][i])
+	}
+
+	// Bipartite: targets in a separate range, so equal indices are not self-loops and can be sampled. Here they are
+	// the only pairs that are not existing edges.
+	negativesT, err = NegativeSampling(tensors.FromValue([][]int32{{0, 1}, {1, 0}}), 2, 20, UniformNegatives).
+		Bipartite(2).Done()
+	require.NoError(t, err)
+	negatives = negativesT.Value().([][]int32)
+	for i := range negatives[0] {
+		require.Equal(t, negatives[0][i], negatives[1][i])
+	}
+
+	// Complete graph: no negatives available.
+	_, err = NegativeSampling(tensors.FromValue([][]int32{{0, 1}, {1, 0}}), 2, 1, UniformNegatives).Done()
+	require.Error(t, err)
+	_, err = NegativeSampling(tensors.FromValue([][]int32{{0, 1}, {1, 2}}), 2, 1, UniformNegatives).Done()
+	require.Error(t, err)
+	_, err = NegativeSampling(edges, 10, 1, NegativeSamplingMethod(5)).Done()
+	require.Error(t, err)
+}
@@ -0,0 +1,320 @@
+package graph
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// PaddingBudget is the static size of a padded batch of graphs. Since GoMLX compiles one computation graph per
+// shape, padding all batches to a few budgets avoids recompilations.
+type PaddingBudget struct {
+	NumNodes, NumEdges, NumGraphs int
+}
+
+// String implements fmt.Stringer.
+func (b PaddingBudget) String() string {
+	return fmt.Sprintf("PaddingBudget{NumNodes: %d, NumEdges: %d, NumGraphs: %d}", b.NumNodes, b.NumEdges, b.NumGraphs)
+}
+
+// NextPowerOfTwo returns the smallest power of two >= n. It returns 1 for n <= 1.
+func NextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// PowerOfTwoBudget returns the smallest budget with power-of-two sizes that can hold the given batch, including
+// room for the padding graph and one padding node, as required by Pad.
+//
+// Bucketing sizes to powers of two limits the number of distinct shapes (and hence recompilations) to
+// a logarithmic number, at the cost of up to 2x padding.
+func PowerOfTwoBudget(batched *BatchedGraph) PaddingBudget {
+	return PaddingBudget{
+		NumNodes:  NextPowerOfTwo(batched.NumNodes + 1),
+		NumEdges:  NextPowerOfTwo(batched.NumEdges()),
+		NumGraphs: NextPowerOfTwo(batched.NumGraphs + 1),
+	}
+}
+
+// PaddedGraph is a BatchedGraph padded to a static PaddingBudget, as created by Pad.
+type PaddedGraph struct {
+	*BatchedGraph
+
+	// NodeMask shaped [Budget.NumNodes]Bool is true for the real nodes and false for padding nodes.
+	NodeMask *tensors.Tensor
+
+	// EdgeMask shaped [Budget.NumEdges]Bool is true for the real edges and false for padding edges.
+	// It can be used as the logitsMask of layers.SparseSoftmax.
+	EdgeMask *tensors.Tensor
+
+	// GraphMask shaped [Budget.NumGraphs]Bool is true for the real graphs and false for padding graphs.
+	GraphMask *tensors.Tensor
+
+	// NumRealNodes, NumRealEdges and NumRealGraphs are the sizes of the batch before padding.
+	NumRealNodes, NumRealEdges, NumRealGraphs int
+}
+
+// Pad pads the batched graph to the static sizes of the budget.
+//
+// Padding follows the convention of TF-GNN: all padding nodes and edges belong to a padding graph (the first graph
+// after the real ones), and padding edges connect the first padding node to itself, so they don't affect the real
+// nodes in message passing. Further padding graphs are empty. Features, positions and labels are padded with zeros.
+//
+// Because of that, if the budget has more nodes or edges than the batch, it must also have at least one extra graph
+// and one extra node. It returns an error if the batch doesn't fit the budget.
+func Pad(batched *BatchedGraph, budget PaddingBudget) (*PaddedGraph, error) {
+	numNodes, numEdges, numGraphs := batched.NumNodes, batched.NumEdges(), batched.NumGraphs
+	if budget.NumNodes < numNodes || budget.NumEdges < numEdges || budget.NumGraphs < numGraphs {
+		return nil, fmt.Errorf("batch (NumNodes: %d, NumEdges: %d, NumGraphs: %d) doesn't fit %s",
+			numNodes, numEdges, numGraphs, budget)
+	}
+	needsPadding := budget.NumNodes > numNodes || budget.NumEdges > numEdges
+	if needsPadding && (budget.NumNodes == numNodes || budget.NumGraphs == numGraphs) {
+		return nil, fmt.Errorf("padding batch (NumNodes: %d, NumEdges: %d, NumGraphs: %d) to %s requires room for "+
+			"at least one padding node and one padding graph", numNodes, numEdges, numGraphs, budget)
+	}
+	if batched.NodePtr == nil || batched.EdgePtr == nil || batched.NodeBatch == nil {
+		return nil, fmt.Errorf("invalid BatchedGraph, NodeBatch, NodePtr and EdgePtr must be set")
+	}
+
+	padded := &PaddedGraph{
+		NumRealNodes:  numNodes,
+		NumRealEdges:  numEdges,
+		NumRealGraphs: numGraphs,
+		NodeMask:      maskTensor(numNodes, budget.NumNodes),
+		EdgeMask:      maskTensor(numEdges, budget.NumEdges),
+		GraphMask:     maskTensor(numGraphs, budget.NumGraphs),
+	}
+
+	// Edges: padding edges are self-loops on the first padding node.
+	paddingNode := int32(numNodes)
+	edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, budget.NumEdges))
+	tensors.MutableFlatData(edges, func(flatEdges []int32) {
+		tensors.ConstFlatData(batched.Edges, func(flat []int32) {
+			copy(flatEdges[:numEdges], flat[:numEdges])
+			copy(flatEdges[budget.NumEdges:budget.NumEdges+numEdges], flat[numEdges:])
+		})
+		for i := numEdges; i < budget.NumEdges; i++ {
+			flatEdges[i] = paddingNode
+			flatEdges[budget.NumEdges+i] = paddingNode
+		}
+	})
+
+	// Padding nodes and edges belong to the first padding graph.
+	nodeBatch := make([]int32, budget.NumNodes)
+	tensors.ConstFlatData(batched.NodeBatch, func(flat []int32) { copy(nodeBatch, flat) })
+	for i := numNodes; i < budget.NumNodes; i++ {
+		nodeBatch[i] = int32(numGraphs)
+	}
+	nodePtr := make([]int32, budget.NumGraphs+1)
+	edgePtr := make([]int32, budget.NumGraphs+1)
+	tensors.ConstFlatData(batched.NodePtr, func(flat []int32) { copy(nodePtr, flat) })
+	tensors.ConstFlatData(batched.EdgePtr, func(flat []int32) { copy(edgePtr, flat) })
+	for i := numGraphs + 1; i <= budget.NumGraphs; i++ {
+		nodePtr[i] = int32(budget.NumNodes)
+		edgePtr[i] = int32(budget.NumEdges)
+	}
+
+	padded.BatchedGraph = &BatchedGraph{
+		Graph:           New(budget.NumNodes, edges),
+		NumGraphs:       budget.NumGraphs,
+		NodeBatch:       tensors.FromValue(nodeBatch),
+		NodePtr:         tensors.FromValue(nodePtr),
+		EdgePtr:         tensors.FromValue(edgePtr),
+		NodeLevelLabels: batched.NodeLevelLabels,
+	}
+	padded.NodeFeatures = padRows(batched.NodeFeatures, budget.NumNodes)
+	padded.Positions = padRows(batched.Positions, budget.NumNodes)
+	padded.EdgeFeatures = padRows(batched.EdgeFeatures, budget.NumEdges)
+	if batched.NodeLevelLabels {
+		padded.Labels = padRows(batched.Labels, budget.NumNodes)
+	} else {
+		padded.Labels = padRows(batched.Labels, budget.NumGraphs)
+	}
+	return padded, nil
+}
+
+// Unpad removes the padding added by Pad, returning the original BatchedGraph.
+//
+// It returns an error if the padded batch has no real nodes, edges or graphs, since the unpadded tensors would
+// be empty, which is not supported.
+func Unpad(padded *PaddedGraph) (*BatchedGraph, error) {
+	if padded.NumRealNodes <= 0 || padded.NumRealEdges <= 0 || padded.NumRealGraphs <= 0 {
+		return nil, fmt.Errorf("cannot unpad batch (NumRealNodes: %d, NumRealEdges: %d, NumRealGraphs: %d): "+
+			"empty tensors are not supported", padded.NumRealNodes, padded.NumRealEdges, padded.NumRealGraphs)
+	}
+	nodeRange := rangeIndices(0, int32(padded.NumRealNodes))
+	edgeRange := rangeIndices(0, int32(padded.NumRealEdges))
+	batched := &BatchedGraph{
+		Graph:           New(padded.NumRealNodes, nil),
+		NumGraphs:       padded.NumRealGraphs,
+		NodeBatch:       GatherRows(padded.NodeBatch, nodeRange),
+		NodePtr:         GatherRows(padded.NodePtr, rangeIndices(0, int32(padded.NumRealGraphs+1))),
+		EdgePtr:         GatherRows(padded.EdgePtr, rangeIndices(0, int32(padded.NumRealGraphs+1))),
+		NodeLevelLabels: padded.NodeLevelLabels,
+	}
+	numEdges := padded.NumRealEdges
+	edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	tensors.MutableFlatData(edges, func(flatEdges []int32) {
+		tensors.ConstFlatData(padded.Edges, func(flat []int32) {
+			copy(flatEdges[:numEdges], flat[:numEdges])
+			copy(flatEdges[numEdges:], flat[padded.NumEdges():padded.NumEdges()+numEdges])
+		})
+	})
+	batched.Edges = edges
+	if padded.NodeFeatures != nil {
+		batched.NodeFeatures = GatherRows(padded.NodeFeatures, nodeRange)
+	}
+	if padded.Positions != nil {
+		batched.Positions = GatherRows(padded.Positions, nodeRange)
+	}
+	if padded.EdgeFeatures != nil {
+		batched.EdgeFeatures = GatherRows(padded.EdgeFeatures, edgeRange)
+	}
+	if padded.Labels != nil {
+		if padded.NodeLevelLabels {
+			batched.Labels = GatherRows(padded.Labels, nodeRange)
+		} else {
+			batched.Labels = GatherRows(padded.Labels, rangeIndices(0, int32(padded.NumRealGraphs)))
+		}
+	}
+	return batched, nil
+}
+
+// maskTensor returns a [size]Bool tensor with the first numTrue values set to true.
+func maskTensor(numTrue, size int) *tensors.Tensor {
+	mask := make([]bool, size)
+	for i := range numTrue {
+		mask[i] = true
+	}
+	return tensors.FromValue(mask)
+}
+
+// padRows returns a copy of t padded with zeros along the first axis to numRows. It returns nil if t is nil.
+func padRows(t *tensors.Tensor, numRows int) *tensors.Tensor {
+	if t == nil {
+		return nil
+	}
+	dims := t.Shape().Clone().Dimensions
+	dims[0] = numRows
+	output := tensors.FromShape(shapes.Make(t.DType(), dims...))
+	t.ConstBytes(func(data []byte) {
+		output.MutableBytes(func(outputData []byte) {
+			n := copy(outputData, data)
+			clear(outputData[n:])
+		})
+	})
+	return output
+}
+
+// EstimateBudgetConfig is created with EstimateBudget and once fully configured, can be executed
+// with Done.
+type EstimateBudgetConfig struct {
+	numNodes, numEdges []int
+	batchSize          int
+	percentile         float64
+	numSamples         int
+	seed               uint64
+	powerOfTwo         bool
+}
+
+// EstimateBudget estimates a PaddingBudget for batches of batchSize graphs randomly sampled from a dataset,
+// such that a target percentile of the batches fit the budget.
+//
+// It works by sampling random batches (with a seeded RNG, so it is deterministic) and taking the percentile of
+// the total number of nodes and edges. Notice the percentile is taken independently for nodes and edges, so the
+// fraction of batches that fit both may be somewhat lower. Batches that don't fit can be split or skipped.
+//
+// Args:
+//   - numNodes, numEdges: the number of nodes and edges of each graph in the dataset.
+//   - batchSize: number of graphs per batch.
+//
+// It returns a configuration that can be optionally configured. Call EstimateBudgetConfig.Done to perform
+// the operation.
+func EstimateBudget(numNodes, numEdges []int, batchSize int) *EstimateBudgetConfig {
+	return &EstimateBudgetConfig{
+		numNodes:   numNodes,
+		numEdges:   numEdges,
+		batchSize:  batchSize,
+		percentile: 99,
+		numSamples: 1000,
+		seed:       42,
+	}
+}
+
+// Percentile of the sampled batches that should fit the budget, in the range (0, 100]. Default is 99.
+func (c *EstimateBudgetConfig) Percentile(percentile float64) *EstimateBudgetConfig {
+	c.percentile = percentile
+	return c
+}
+
+// NumSamples sets the number of random batches sampled to estimate the budget. Default is 1000.
+func (c *EstimateBudgetConfig) NumSamples(numSamples int) *EstimateBudgetConfig {
+	c.numSamples = numSamples
+	return c
+}
+
+// Seed sets the seed of the random number generator used to sample the batches. Default is 42.
+func (c *EstimateBudgetConfig) Seed(seed uint64) *EstimateBudgetConfig {
+	c.seed = seed
+	return c
+}
+
+// PowerOfTwo rounds the budget sizes up to the next power of two.
+func (c *EstimateBudgetConfig) PowerOfTwo() *EstimateBudgetConfig {
+	c.powerOfTwo = true
+	return c
+}
+
+// Done performs the estimation as configured.
+//
+// The returned budget includes the room for one padding node and one padding graph required by Pad.
+func (c *EstimateBudgetConfig) Done() (PaddingBudget, error) {
+	if len(c.numNodes) == 0 || len(c.numNodes) != len(c.numEdges) {
+		return PaddingBudget{}, fmt.Errorf("numNodes (len=%d) and numEdges (len=%d) must be non-empty and have the same length",
+			len(c.numNodes), len(c.numEdges))
+	}
+	if c.batchSize <= 0 || c.numSamples <= 0 {
+		return PaddingBudget{}, fmt.Errorf("batchSize (%d) and numSamples (%d) must be positive", c.batchSize, c.numSamples)
+	}
+	if c.percentile <= 0 || c.percentile > 100 {
+		return PaddingBudget{}, fmt.Errorf("percentile (%g) must be in the range (0, 100]", c.percentile)
+	}
+	rng := rand.New(rand.NewPCG(c.seed, 0))
+	nodeTotals := make([]int, c.numSamples)
+	edgeTotals := make([]int, c.numSamples)
+	for sample := range c.numSamples {
+		for range c.batchSize {
+			graphIdx := rng.IntN(len(c.numNodes))
+			nodeTotals[sample] += c.numNodes[graphIdx]
+			edgeTotals[sample] += c.numEdges[graphIdx]
+		}
+	}
+	budget := PaddingBudget{
+		NumNodes:  percentileOf(nodeTotals, c.percentile) + 1,
+		NumEdges:  percentileOf(edgeTotals, c.percentile),
+		NumGraphs: c.batchSize + 1,
+	}
+	if c.powerOfTwo {
+		budget.NumNodes = NextPowerOfTwo(budget.NumNodes)
+		budget.NumEdges = NextPowerOfTwo(budget.NumEdges)
+		budget.NumGraphs = NextPowerOfTwo(budget.NumGraphs)
+	}
+	return budget, nil
+}
+
+// percentileOf returns the smallest value such that at least percentile% of the values are <= to it.
+// It sorts values in-place.
+func percentileOf(values []int, percentile float64) int {
+	slices.Sort(values)
+	idx := int(float64(len(values))*percentile/100+0.999999) - 1
+	idx = min(max(idx, 0), len(values)-1)
+	return values[idx]
+}
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextPowerOfTwo(t *testing.T) {
+	for n, want := range map[int]int{0: 1, 1: 1, 2: 2, 3: 4, 4: 4, 5: 8, 1000: 1024} {
+		require.Equal(t, want, NextPowerOfTwo(n), "NextPowerOfTwo(%d)", n)
+	}
+}
+
+func TestPad(t *testing.T) {
+	g0 := New(2, tensors.FromValue([][]int32{{0, 1}, {1, 0}}))
+	g0.NodeFeatures = tensors.FromValue([][]float32{{0}, {1}})
+	g0.EdgeFeatures = tensors.FromValue([]int32{10, 11})
+	g0.Labels = tensors.FromScalar(int32(7))
+	g1 := New(3, tensors.FromValue([][]int32{{0, 2, 2}, {1, 0, 1}}))
+	g1.NodeFeatures = tensors.FromValue([][]float32{{2}, {3}, {4}})
+	g1.EdgeFeatures = tensors.FromValue([]int32{12, 13, 14})
+	g1.Labels = tensors.FromScalar(int32(8))
+	batched, err := Batch(g0, g1).Done()
+	require.NoError(t, err)
+
+	budget := PowerOfTwoBudget(batched)
+	require.Equal(t, PaddingBudget{NumNodes: 8, NumEdges: 8, NumGraphs: 4}, budget)
+	padded, err := Pad(batched, budget)
+	require.NoError(t, err)
+	require.NoError(t, padded.Validate())
+	require.Equal(t, 8, padded.NumNodes)
+	require.Equal(t, 4, padded.NumGraphs)
+	require.Equal(t, [][]int32{{0, 1, 2, 4, 4, 5, 5, 5}, {1, 0, 3, 2, 3, 5, 5, 5}}, padded.Edges.Value())
+	require.Equal(t, [][]float32{{0}, {1}, {2}, {3}, {4}, {0}, {0}, {0}}, padded.NodeFeatures.Value())
+	require.Equal(t, []int32{10, 11, 12, 13, 14, 0, 0, 0}, padded.EdgeFeatures.Value())
+	require.Equal(t, []int32{7, 8, 0, 0}, padded.Labels.Value())
+	require.Equal(t, []int32{0, 0, 1, 1, 1, 2, 2, 2}, padded.NodeBatch.Value())
+	require.Equal(t, []int32{0, 2, 5, 8, 8}, padded.NodePtr.Value())
+	require.Equal(t, []int32{0, 2, 5, 8, 8}, padded.EdgePtr.Value())
+	require.Equal(t, []bool{true, true, true, true, true, false, false, false}, padded.NodeMask.Value())
+	require.Equal(t, []bool{true, true, true, true, true, false, false, false}, padded.EdgeMask.Value())
+	require.Equal(t, []bool{true, true, false, false}, padded.GraphMask.Value())
+
+	unpadded, err := Unpad(padded)
+	require.NoError(t, err)
+	require.Equal(t, batched.NumNodes, unpadded.NumNodes)
+	require.Equal(t, batched.NumGraphs, unpadded.NumGraphs)
+	require.Equal(t, batched.Edges.Value(), unpadded.Edges.Value())
+	require.Equal(t, batched.NodeFeatures.Value(), unpadded.NodeFeatures.Value())
+	require.Equal(t, batched.EdgeFeatures.Value(), unpadded.EdgeFeatures.Value())
+	require.Equal(t, batched.Labels.Value(), unpadded.Labels.Value())
+	require.Equal(t, batched.NodePtr.Value(), unpadded.NodePtr.Value())
+	require.Equal(t, batched.EdgePtr.Value(), unpadded.EdgePtr.Value())
+
+	// A padded batch without real edges can't be unpadded into an empty edges tensor.
+	noEdges := *padded
+	noEdges.NumRealEdges = 0
+	_, err = Unpad(&noEdges)
+	require.ErrorContains(t, err, "NumRealEdges: 0")
+
+	// Exact fit requires no padding graph.
+	_, err = Pad(batched, PaddingBudget{NumNodes: 5, NumEdges: 5, NumGraphs: 2})
+	require.NoError(t, err)
+
+	// Batch doesn't fit, or no room for the padding node or graph.
+	_, err = Pad(batched, PaddingBudget{NumNodes: 4, NumEdges: 8, NumGraphs: 4})
+	require.Error(t, err)
+	_, err = Pad(batched, PaddingBudget{NumNodes: 5, NumEdges: 8, NumGraphs: 4})
+	require.Error(t, err)
+	_, err = Pad(batched, PaddingBudget{NumNodes: 8, NumEdges: 8, NumGraphs: 2})
+	require.Error(t, err)
+}
+
+func TestEstimateBudget(t *testing.T) {
+	numNodes := []int{10, 10, 10, 10}
+	numEdges := []int{20, 20, 20, 20}
+	budget, err := EstimateBudget(numNodes, numEdges, 4).Done()
+	require.NoError(t, err)
+	require.Equal(t, PaddingBudget{NumNodes: 41, NumEdges: 80, NumGraphs: 5}, budget)
+
+	budget, err = EstimateBudget(numNodes, numEdges, 4).PowerOfTwo().Done()
+	require.NoError(t, err)
+	require.Equal(t, PaddingBudget{NumNodes: 64, NumEdges: 128, NumGraphs: 8}, budget)
+
+	// A higher percentile never requires a smaller budget, and 100% covers the largest sampled batch.
+	numNodes = []int{1, 2, 3, 50}
+	numEdges = []int{1, 2, 3, 100}
+	low, err := EstimateBudget(numNodes, numEdges, 2).Percentile(50).Done()
+	require.NoError(t, err)
+	high, err := EstimateBudget(numNodes, numEdges, 2).Percentile(100).Done()
+	require.NoError(t, err)
+	require.LessOrEqual(t, low.NumNodes, high.NumNodes)
+	require.LessOrEqual(t, low.NumEdges, high.NumEdges)
+	require.Equal(t, PaddingBudget{NumNodes: 101, NumEdges: 200, NumGraphs: 3}, high)
+
+	_, err = EstimateBudget(numNodes, numEdges[:2], 2).Done()
+	require.Error(t, err)
+	_, err = EstimateBudget(numNodes, numEdges, 2).Percentile(0).Done()
+	require.Error(t, err)
+}
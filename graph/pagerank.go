@@ -0,0 +1,417 @@
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// PageRankConfig is created with PageRank and once fully configured, can be executed
+// with Done.
+type PageRankConfig struct {
+	edges         *tensors.Tensor
+	numNodes      int
+	alpha         float64
+	sources       []int32
+	tolerance     float64
+	maxIterations int
+	numWorkers    int
+}
+
+// PageRank computes the PageRank scores of the nodes by power iteration: the stationary distribution of a random
+// walk that follows the outgoing edges and, with probability alpha at each step (or always, at nodes without
+// outgoing edges), restarts from a random node.
+//
+// With PageRankConfig.Personalized, the walk restarts from the given source nodes, which gives the personalized
+// PageRank (PPR) scores, as used by APPNP. See also PPRDiffusion for sparse top-k PPR neighborhoods.
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32. Duplicate edges increase the probability of the transition.
+//   - numNodes: number of nodes of the graph.
+//
+// It returns a configuration that can be optionally configured. Call PageRankConfig.Done to perform
+// the operation.
+func PageRank(edges *tensors.Tensor, numNodes int) *PageRankConfig {
+	return &PageRankConfig{
+		edges:         edges,
+		numNodes:      numNodes,
+		alpha:         0.15,
+		tolerance:     1e-6,
+		maxIterations: 100,
+		numWorkers:    runtime.NumCPU(),
+	}
+}
+
+// Alpha sets the teleport (restart) probability, in (0, 1]. Default is 0.15, which corresponds to the usual
+// damping factor of 0.85.
+func (c *PageRankConfig) Alpha(alpha float64) *PageRankConfig {
+	c.alpha = alpha
+	return c
+}
+
+// Personalized makes the walk restart uniformly from the given source nodes, instead of from any node.
+func (c *PageRankConfig) Personalized(sources []int32) *PageRankConfig {
+	c.sources = sources
+	return c
+}
+
+// Tolerance sets the convergence criterion: the iterations stop when the L1 change of the scores is below
+// tolerance. Default is 1e-6.
+func (c *PageRankConfig) Tolerance(tolerance float64) *PageRankConfig {
+	c.tolerance = tolerance
+	return c
+}
+
+// MaxIterations sets the maximum number of iterations. Default is 100.
+func (c *PageRankConfig) MaxIterations(maxIterations int) *PageRankConfig {
+	c.maxIterations = maxIterations
+	return c
+}
+
+// NumWorkers sets the number of goroutines used in each iteration. Default is runtime.NumCPU().
+// The scores don't depend on the number of workers.
+func (c *PageRankConfig) NumWorkers(numWorkers int) *PageRankConfig {
+	c.numWorkers = numWorkers
+	return c
+}
+
+// Done performs the power iteration as configured.
+//
+// It returns the scores shaped [numNodes]Float32, which add up to 1. It doesn't return an error if the maximum
+// number of iterations is reached before convergence.
+func (c *PageRankConfig) Done() (*tensors.Tensor, error) {
+	if c.alpha <= 0 || c.alpha > 1 {
+		return nil, fmt.Errorf("invalid alpha %g, it must be in (0, 1]", c.alpha)
+	}
+	if c.tolerance < 0 || c.maxIterations <= 0 || c.numWorkers <= 0 {
+		return nil, fmt.Errorf("invalid tolerance (%g), maxIterations (%d) or numWorkers (%d)",
+			c.tolerance, c.maxIterations, c.numWorkers)
+	}
+	csc, err := ToCSC(c.edges, c.numNodes)
+	if err != nil {
+		return nil, err
+	}
+	numNodes := c.numNodes
+	restart := make([]float64, numNodes)
+	if c.sources == nil {
+		for node := range restart {
+			restart[node] = 1 / float64(numNodes)
+		}
+	} else {
+		if len(c.sources) == 0 {
+			return nil, fmt.Errorf("no personalization source nodes given")
+		}
+		for _, source := range c.sources {
+			if source < 0 || int(source) >= numNodes {
+				return nil, fmt.Errorf("source node %d out of range, there are %d nodes", source, numNodes)
+			}
+			restart[source] += 1 / float64(len(c.sources))
+		}
+	}
+	outDegrees := make([]int32, numNodes)
+	for _, source := range csc.Indices {
+		outDegrees[source]++
+	}
+
+	scores := slices.Clone(restart)
+	next := make([]float64, numNodes)
+	// contributions[u] = scores[u] / outDegree[u], so the inner loop is a sum.
+	contributions := make([]float64, numNodes)
+	numWorkers := min(c.numWorkers, max(numNodes/1024, 1))
+	changes := make([]float64, numWorkers)
+	for range c.maxIterations {
+		var dangling float64
+		for node, score := range scores {
+			if outDegrees[node] == 0 {
+				dangling += score
+				contributions[node] = 0
+			} else {
+				contributions[node] = score / float64(outDegrees[node])
+			}
+		}
+		var wg sync.WaitGroup
+		for worker := range numWorkers {
+			start, end := worker*numNodes/numWorkers, (worker+1)*numNodes/numWorkers
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var change float64
+				for node := start; node < end; node++ {
+					var sum float64
+					for _, source := range csc.Indices[csc.Ptr[node]:csc.Ptr[node+1]] {
+						sum += contributions[source]
+					}
+					next[node] = c.alpha*restart[node] + (1-c.alpha)*(sum+dangling*restart[node])
+					change += math.Abs(next[node] - scores[node])
+				}
+				changes[worker] = change
+			}()
+		}
+		wg.Wait()
+		scores, next = next, scores
+		var change float64
+		for _, workerChange := range changes {
+			change += workerChange
+		}
+		if change < c.tolerance {
+			break
+		}
+	}
+	result := make([]float32, numNodes)
+	for node, score := range scores {
+		result[node] = float32(score)
+	}
+	return tensors.FromValue(result), nil
+}
+
+// PPRDiffusionConfig is created with PPRDiffusion and once fully configured, can be executed
+// with Done.
+type PPRDiffusionConfig struct {
+	edges      *tensors.Tensor
+	numNodes   int
+	alpha      float64
+	epsilon    float64
+	topK       int
+	targets    []int32
+	numWorkers int
+}
+
+// PPRDiffusion computes the sparse personalized PageRank (PPR) neighborhood of each node, with the approximate
+// push algorithm of Andersen et al. (2006), and keeps the topK nodes with the highest scores: the diffusion graph
+// of GDC (graph diffusion convolution) and PPRGo.
+//
+// The PPR of a node t is a random walk following the outgoing edges, and restarting at t with probability alpha.
+// The push algorithm estimates the scores with a residual below epsilon * outDegree for every node, and only
+// touches the nodes close to t, so it scales to large graphs.
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32. Use ToUndirected for undirected graphs.
+//   - numNodes: number of nodes of the graph.
+//
+// It returns a configuration that can be optionally configured. Call PPRDiffusionConfig.Done to perform
+// the operation.
+func PPRDiffusion(edges *tensors.Tensor, numNodes int) *PPRDiffusionConfig {
+	return &PPRDiffusionConfig{
+		edges:      edges,
+		numNodes:   numNodes,
+		alpha:      0.15,
+		epsilon:    1e-4,
+		topK:       32,
+		numWorkers: runtime.NumCPU(),
+	}
+}
+
+// Alpha sets the teleport (restart) probability, in (0, 1]. Default is 0.15.
+func (c *PPRDiffusionConfig) Alpha(alpha float64) *PPRDiffusionConfig {
+	c.alpha = alpha
+	return c
+}
+
+// Epsilon sets the approximation threshold of the push algorithm: smaller values are more accurate but touch more
+// nodes. Default is 1e-4.
+func (c *PPRDiffusionConfig) Epsilon(epsilon float64) *PPRDiffusionConfig {
+	c.epsilon = epsilon
+	return c
+}
+
+// TopK sets the maximum number of diffusion edges per node. Default is 32.
+func (c *PPRDiffusionConfig) TopK(topK int) *PPRDiffusionConfig {
+	c.topK = topK
+	return c
+}
+
+// Targets sets the nodes whose PPR neighborhoods are computed. Default is all nodes.
+func (c *PPRDiffusionConfig) Targets(targets []int32) *PPRDiffusionConfig {
+	c.targets = targets
+	return c
+}
+
+// NumWorkers sets the number of goroutines used, each handling a range of the target nodes.
+// Default is runtime.NumCPU(). The result doesn't depend on the number of workers.
+func (c *PPRDiffusionConfig) NumWorkers(numWorkers int) *PPRDiffusionConfig {
+	c.numWorkers = numWorkers
+	return c
+}
+
+// Done computes the diffusion as configured.
+//
+// It returns the diffusion edges shaped [2, numDiffusionEdges]Int32 and their weights (the approximate PPR scores)
+// shaped [numDiffusionEdges]Float32. The edge v->t means that v is in the top-k PPR neighborhood of t, so that
+// message passing aggregates at each target node the messages from its neighborhood (using the weights, e.g.,
+// as EdgeFeatures). The edges are grouped by target, in the order of the targets, and sorted by decreasing weight.
+// It includes self-loops, since the PPR of a node to itself is at least alpha: every target is pushed at least
+// once, even if its out-degree is above 1/epsilon. It returns an error if there are no diffusion edges.
+func (c *PPRDiffusionConfig) Done() (edges, weights *tensors.Tensor, err error) {
+	if c.alpha <= 0 || c.alpha > 1 {
+		return nil, nil, fmt.Errorf("invalid alpha %g, it must be in (0, 1]", c.alpha)
+	}
+	if c.epsilon <= 0 || c.topK <= 0 || c.numWorkers <= 0 {
+		return nil, nil, fmt.Errorf("epsilon (%g), topK (%d) and numWorkers (%d) must be positive",
+			c.epsilon, c.topK, c.numWorkers)
+	}
+	adj, err := NewAdjacency(c.edges, c.numNodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	targets := c.targets
+	if targets == nil {
+		targets = rangeIndices(0, int32(c.numNodes))
+	}
+	for _, target := range targets {
+		if target < 0 || int(target) >= c.numNodes {
+			return nil, nil, fmt.Errorf("target node %d out of range, there are %d nodes", target, c.numNodes)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, nil, fmt.Errorf("no target nodes given")
+	}
+
+	// Each target has its own list of (node, score) pairs.
+	neighborhoods := make([][]pprScore, len(targets))
+	numWorkers := min(c.numWorkers, len(targets))
+	var wg sync.WaitGroup
+	for worker := range numWorkers {
+		start, end := worker*len(targets)/numWorkers, (worker+1)*len(targets)/numWorkers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := newPPRPush(adj, c.alpha, c.epsilon)
+			for i := start; i < end; i++ {
+				neighborhoods[i] = p.topK(targets[i], c.topK)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var sources, diffusionTargets []int32
+	var flatWeights []float32
+	for i, neighborhood := range neighborhoods {
+		for _, s := range neighborhood {
+			sources = append(sources, s.node)
+			diffusionTargets = append(diffusionTargets, targets[i])
+			flatWeights = append(flatWeights, float32(s.score))
+		}
+	}
+	if len(flatWeights) == 0 {
+		return nil, nil, fmt.Errorf("no diffusion edges found for the %d target nodes", len(targets))
+	}
+	return edgesFromSlices(sources, diffusionTargets), tensors.FromValue(flatWeights), nil
+}
+
+// pprScore is the approximate PPR score of a node.
+type pprScore struct {
+	node  int32
+	score float64
+}
+
+// pprPush holds the buffers of the push algorithm, reused across targets.
+type pprPush struct {
+	adj             *Adjacency
+	alpha, epsilon  float64
+	scores, residue []float64
+	queued          []bool
+	touched, queue  []int32
+}
+
+func newPPRPush(adj *Adjacency, alpha, epsilon float64) *pprPush {
+	numNodes := adj.NumNodes()
+	return &pprPush{
+		adj:     adj,
+		alpha:   alpha,
+		epsilon: epsilon,
+		scores:  make([]float64, numNodes),
+		residue: make([]float64, numNodes),
+		queued:  make([]bool, numNodes),
+	}
+}
+
+// topK runs the push algorithm from target, and returns the k nodes with the highest scores, sorted by decreasing
+// score (and increasing node index for ties). The buffers are left zeroed.
+func (p *pprPush) topK(target int32, k int) []pprScore {
+	threshold := func(node int32) float64 {
+		return p.epsilon * float64(max(p.adj.OutDegree(int(node)), 1))
+	}
+	touch := func(node int32) {
+		if p.residue[node] == 0 && p.scores[node] == 0 {
+			p.touched = append(p.touched, node)
+		}
+	}
+	addResidue := func(node int32, value float64) {
+		touch(node)
+		p.residue[node] += value
+		if !p.queued[node] && p.residue[node] >= threshold(node) {
+			p.queued[node] = true
+			p.queue = append(p.queue, node)
+		}
+	}
+	// The target is always pushed once, as in PPRGo, even if its residue is below the threshold (for nodes with
+	// out-degree above 1/epsilon), so every target gets at least its own score.
+	touch(target)
+	p.residue[target] = 1
+	p.queued[target] = true
+	p.queue = append(p.queue, target)
+	for head := 0; head < len(p.queue); head++ {
+		node := p.queue[head]
+		p.queued[node] = false
+		residue := p.residue[node]
+		p.residue[node] = 0
+		p.scores[node] += p.alpha * residue
+		push := (1 - p.alpha) * residue
+		neighbors := p.adj.Neighbors(int(node))
+		if len(neighbors) == 0 {
+			// Dangling nodes restart the walk.
+			addResidue(target, push)
+			continue
+		}
+		push /= float64(len(neighbors))
+		for _, neighbor := range neighbors {
+			addResidue(neighbor, push)
+		}
+	}
+
+	var result []pprScore
+	for _, node := range p.touched {
+		if p.scores[node] > 0 {
+			result = append(result, pprScore{node, p.scores[node]})
+		}
+		p.scores[node], p.residue[node] = 0, 0
+	}
+	p.touched, p.queue = p.touched[:0], p.queue[:0]
+	slices.SortFunc(result, func(a, b pprScore) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.node, b.node)
+	})
+	return result[:min(k, len(result))]
+}
+
+// PPRDiffusionTransform returns a Transform that replaces the edges of a graph by its PPR diffusion edges (see
+// PPRDiffusion), as PyG's GDC, with the weights as EdgeFeatures shaped [numEdges, 1]Float32. Any previous
+// EdgeFeatures are dropped.
+//
+// It is registered as the "ppr_diffusion" transform, with parameters "alpha" (default 0.15), "epsilon"
+// (default 1e-4) and "top_k" (default 32).
+func PPRDiffusionTransform(alpha, epsilon float64, topK int) Transform {
+	return TransformFunc(func(g *Graph) error {
+		if err := g.Validate(); err != nil {
+			return err
+		}
+		edges, weights, err := PPRDiffusion(g.Edges, g.NumNodes).Alpha(alpha).Epsilon(epsilon).TopK(topK).Done()
+		if err != nil {
+			return err
+		}
+		g.Edges = edges
+		g.EdgeFeatures = tensors.FromFlatDataAndDimensions(weights.Value().([]float32), weights.Shape().Size(), 1)
+		return nil
+	})
+}
@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageRank(t *testing.T) {
+	// Symmetric graphs have uniform scores.
+	scores, err := PageRank(cycleEdges(10), 10).Done()
+	require.NoError(t, err)
+	for _, score := range scores.Value().([]float32) {
+		require.InDelta(t, 0.1, score, 1e-6)
+	}
+
+	// Star: all nodes point to 0, and 0 is dangling, so it restarts uniformly.
+	scores, err = PageRank(tensors.FromValue([][]int32{{1, 2, 3}, {0, 0, 0}}), 4).Tolerance(1e-9).Done()
+	require.NoError(t, err)
+	got := scores.Value().([]float32)
+	const alpha, n = 0.15, 4.0
+	var total float32
+	for _, score := range got {
+		total += score
+	}
+	require.InDelta(t, 1.0, total, 1e-5)
+	require.InDelta(t, got[1], got[2], 1e-7)
+	require.InDelta(t, got[1], got[3], 1e-7)
+	// s1 = alpha/n + (1-alpha)*s0/n.
+	require.InDelta(t, alpha/n+(1-alpha)*got[0]/n, got[1], 1e-5)
+
+	// Compare with a dense reference, personalized, and for different numbers of workers.
+	const numNodes = 50
+	rng := rand.New(rand.NewPCG(42, 0))
+	edges := randomEdges(rng, 200, numNodes)
+	sources := []int32{3, 7}
+	want := densePageRank(edges, numNodes, 0.2, sources)
+	for _, numWorkers := range []int{1, 3} {
+		scores, err = PageRank(edges, numNodes).Alpha(0.2).Personalized(sources).Tolerance(1e-10).MaxIterations(500).
+			NumWorkers(numWorkers).Done()
+		require.NoError(t, err)
+		require.InDeltaSlice(t, want, scores.Value(), 1e-5)
+	}
+
+	_, err = PageRank(edges, numNodes).Alpha(0).Done()
+	require.Error(t, err)
+	_, err = PageRank(edges, numNodes).Personalized([]int32{}).Done()
+	require.Error(t, err)
+	_, err = PageRank(edges, numNodes).Personalized([]int32{numNodes}).Done()
+	require.Error(t, err)
+}
+
+// densePageRank iterates the PageRank equations on a dense transition matrix.
+func densePageRank(edges *tensors.Tensor, numNodes int, alpha float64, sources []int32) []float32 {
+	restart := make([]float64, numNodes)
+	for _, source := range sources {
+		restart[source] += 1 / float64(len(sources))
+	}
+	g := New(numNodes, edges)
+	sourceNodes, targetNodes := g.Sources(), g.Targets()
+	outDegree := make([]float64, numNodes)
+	for _, source := range sourceNodes {
+		outDegree[source]++
+	}
+	scores := append([]float64(nil), restart...)
+	for range 2000 {
+		next := make([]float64, numNodes)
+		var dangling float64
+		for node, score := range scores {
+			if outDegree[node] == 0 {
+				dangling += score
+			}
+		}
+		for i := range sourceNodes {
+			next[targetNodes[i]] += (1 - alpha) * scores[sourceNodes[i]] / outDegree[sourceNodes[i]]
+		}
+		for node := range next {
+			next[node] += alpha*restart[node] + (1-alpha)*dangling*restart[node]
+		}
+		scores = next
+	}
+	result := make([]float32, numNodes)
+	for node, score := range scores {
+		result[node] = float32(score)
+	}
+	return result
+}
+
+func TestPPRDiffusion(t *testing.T) {
+	const numNodes = 50
+	rng := rand.New(rand.NewPCG(42, 0))
+	edges := randomEdges(rng, 200, numNodes)
+
+	// With a tiny epsilon and no top-k truncation, the scores match the personalized PageRank.
+	targets := []int32{3, 11}
+	diffusion, weights, err := PPRDiffusion(edges, numNodes).Alpha(0.2).Epsilon(1e-9).TopK(numNodes).
+		Targets(targets).Done()
+	require.NoError(t, err)
+	diffusionEdges := diffusion.Value().([][]int32)
+	flatWeights := weights.Value().([]float32)
+	require.Len(t, flatWeights, len(diffusionEdges[0]))
+	got := make(map[[2]int32]float32)
+	for i := range flatWeights {
+		got[[2]int32{diffusionEdges[0][i], diffusionEdges[1][i]}] = flatWeights[i]
+		if i > 0 && diffusionEdges[1][i] == diffusionEdges[1][i-1] {
+			require.LessOrEqual(t, flatWeights[i], flatWeights[i-1], "weights are sorted by target")
+		}
+	}
+	for _, target := range targets {
+		want := densePageRank(edges, numNodes, 0.2, []int32{target})
+		for node, score := range want {
+			require.InDelta(t, score, got[[2]int32{int32(node), target}], 1e-5, "PPR of %d from target %d", node, target)
+		}
+	}
+
+	// Top-k truncation, for all nodes, independent of the number of workers.
+	diffusion, weights, err = PPRDiffusion(edges, numNodes).TopK(4).NumWorkers(1).Done()
+	require.NoError(t, err)
+	other, otherWeights, err := PPRDiffusion(edges, numNodes).TopK(4).NumWorkers(4).Done()
+	require.NoError(t, err)
+	require.Equal(t, diffusion.Value(), other.Value())
+	require.Equal(t, weights.Value(), otherWeights.Value())
+	perTarget := make([]int, numNodes)
+	for _, target := range diffusion.Value().([][]int32)[1] {
+		perTarget[target]++
+	}
+	for node, count := range perTarget {
+		require.LessOrEqual(t, count, 4)
+		require.Greater(t, count, 0, "node %d has at least its self-loop", node)
+	}
+
+	// As a registered transform, rewiring a graph.
+	transform, err := ParseTransforms("ppr_diffusion(top_k=4)")
+	require.NoError(t, err)
+	g := New(numNodes, edges)
+	g.EdgeFeatures = tensors.FromValue(make([]int32, 200))
+	require.NoError(t, transform.Apply(g))
+	require.NoError(t, g.Validate())
+	require.Equal(t, diffusion.Value(), g.Edges.Value())
+	require.Equal(t, []int{len(weights.Value().([]float32)), 1}, g.EdgeFeatures.Shape().Dimensions)
+	_, err = ParseTransforms("ppr_diffusion(top_k=x)")
+	require.Error(t, err)
+
+	// Targets with out-degree above 1/epsilon are still pushed once, and get at least their self-loop.
+	star := tensors.FromValue([][]int32{{0, 0, 0, 1, 2, 3}, {1, 2, 3, 0, 0, 0}})
+	diffusion, weights, err = PPRDiffusion(star, 4).Epsilon(0.9).Targets([]int32{0}).Done()
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0}, {0}}, diffusion.Value())
+	require.InDelta(t, 0.15, weights.Value().([]float32)[0], 1e-6)
+	diffusion, _, err = PPRDiffusion(star, 4).Epsilon(0.01).TopK(4).Targets([]int32{0}).Done()
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0, 1, 2, 3}, {0, 0, 0, 0}}, diffusion.Value())
+
+	_, _, err = PPRDiffusion(edges, numNodes).Epsilon(0).Done()
+	require.Error(t, err)
+	_, _, err = PPRDiffusion(edges, numNodes).Targets([]int32{-1}).Done()
+	require.Error(t, err)
+}
@@ -0,0 +1,468 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// PartitionConfig is created with Partition and once fully configured, can be executed
+// with Done.
+type PartitionConfig struct {
+	edges              *tensors.Tensor
+	numNodes, numParts int
+	imbalance          float64
+	seed               uint64
+	numInitialTrials   int
+	numRefinePasses    int
+}
+
+// Partition splits the nodes of a graph into numParts parts of roughly the same size, minimizing the number of
+// edges between parts (the edge-cut), as used by Cluster-GCN (see ClusterLoader).
+//
+// It is a pure Go multilevel partitioner in the spirit of METIS: the graph is coarsened by collapsing heavy-edge
+// matchings, the coarsest graph is partitioned by greedy region growing, and the partition is projected back
+// level by level, with a greedy boundary refinement at each level. Edge directions are ignored, and parallel
+// edges add up their weights.
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - numNodes: number of nodes of the graph.
+//   - numParts: number of parts, between 1 and numNodes.
+//
+// It returns a configuration that can be optionally configured. Call PartitionConfig.Done to perform
+// the operation.
+func Partition(edges *tensors.Tensor, numNodes, numParts int) *PartitionConfig {
+	return &PartitionConfig{
+		edges:            edges,
+		numNodes:         numNodes,
+		numParts:         numParts,
+		imbalance:        0.05,
+		numInitialTrials: 4,
+		numRefinePasses:  8,
+	}
+}
+
+// Imbalance sets the maximum relative size imbalance of the parts: no part will have more than
+// (1+imbalance) * numNodes/numParts nodes, if possible. Default is 0.05.
+func (c *PartitionConfig) Imbalance(imbalance float64) *PartitionConfig {
+	c.imbalance = imbalance
+	return c
+}
+
+// Seed sets the seed of the random number generator. Default is 0.
+func (c *PartitionConfig) Seed(seed uint64) *PartitionConfig {
+	c.seed = seed
+	return c
+}
+
+// Done performs the partitioning as configured.
+//
+// It returns a tensor shaped [numNodes]Int32 with the part of each node, in the range [0, numParts).
+func (c *PartitionConfig) Done() (*tensors.Tensor, error) {
+	if c.numParts <= 0 || c.numParts > c.numNodes {
+		return nil, fmt.Errorf("invalid number of parts %d for %d nodes", c.numParts, c.numNodes)
+	}
+	if c.imbalance < 0 {
+		return nil, fmt.Errorf("invalid imbalance %g", c.imbalance)
+	}
+	wg, err := newWeightedGraph(c.edges, c.numNodes)
+	if err != nil {
+		return nil, err
+	}
+	if c.numParts == 1 {
+		return tensors.FromValue(make([]int32, c.numNodes)), nil
+	}
+	rng := rand.New(rand.NewPCG(c.seed, 0))
+
+	// Coarsening.
+	levels := []*weightedGraph{wg}
+	var mappings [][]int32
+	coarsenTo := max(15*c.numParts, 100)
+	for {
+		current := levels[len(levels)-1]
+		if current.numNodes() <= coarsenTo {
+			break
+		}
+		coarse, mapping := current.coarsen(rng)
+		if float64(coarse.numNodes()) > 0.95*float64(current.numNodes()) {
+			break
+		}
+		levels = append(levels, coarse)
+		mappings = append(mappings, mapping)
+	}
+
+	// Initial partition of the coarsest graph: best of a few trials.
+	coarsest := levels[len(levels)-1]
+	maxWeight := int64(float64(wg.totalWeight())/float64(c.numParts)*(1+c.imbalance)) + 1
+	var parts []int32
+	bestCut := int64(-1)
+	for range c.numInitialTrials {
+		trial := coarsest.growPartition(rng, c.numParts)
+		coarsest.refine(rng, trial, c.numParts, maxWeight, c.numRefinePasses)
+		if cut := coarsest.edgeCut(trial); bestCut < 0 || cut < bestCut {
+			parts, bestCut = trial, cut
+		}
+	}
+
+	// Uncoarsening, with refinement at each level.
+	for level := len(levels) - 2; level >= 0; level-- {
+		mapping := mappings[level]
+		fineParts := make([]int32, levels[level].numNodes())
+		for nodeIdx, coarseIdx := range mapping {
+			fineParts[nodeIdx] = parts[coarseIdx]
+		}
+		parts = fineParts
+		levels[level].refine(rng, parts, c.numParts, maxWeight, c.numRefinePasses)
+	}
+	return tensors.FromValue(parts), nil
+}
+
+// weightedGraph is an undirected graph in CSR format with node and edge weights, used by Partition and
+// StructuralFeatures.
+type weightedGraph struct {
+	ptr, adj   []int32
+	adjWeight  []int64
+	nodeWeight []int64
+}
+
+// newWeightedGraph creates the symmetric weighted graph, without self-loops and with parallel edges merged.
+func newWeightedGraph(edges *tensors.Tensor, numNodes int) (*weightedGraph, error) {
+	reversed, err := ReverseEdges(edges)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := ToCSR(concatenateEdges(edges, reversed), numNodes)
+	if err != nil {
+		return nil, err
+	}
+	wg := &weightedGraph{
+		ptr:        make([]int32, numNodes+1),
+		nodeWeight: make([]int64, numNodes),
+	}
+	for nodeIdx := range numNodes {
+		wg.nodeWeight[nodeIdx] = 1
+		// Neighbors are sorted, so parallel edges are consecutive.
+		for _, neighbor := range csr.Indices[csr.Ptr[nodeIdx]:csr.Ptr[nodeIdx+1]] {
+			if int(neighbor) == nodeIdx {
+				continue
+			}
+			if n := len(wg.adj); n > int(wg.ptr[nodeIdx]) && wg.adj[n-1] == neighbor {
+				wg.adjWeight[n-1]++
+				continue
+			}
+			wg.adj = append(wg.adj, neighbor)
+			wg.adjWeight = append(wg.adjWeight, 1)
+		}
+		wg.ptr[nodeIdx+1] = int32(len(wg.adj))
+	}
+	return wg, nil
+}
+
+func (wg *weightedGraph) numNodes() int {
+	return len(wg.nodeWeight)
+}
+
+func (wg *weightedGraph) totalWeight() int64 {
+	var total int64
+	for _, w := range wg.nodeWeight {
+		total += w
+	}
+	return total
+}
+
+// edgeCut returns the total weight of the edges between different parts.
+func (wg *weightedGraph) edgeCut(parts []int32) int64 {
+	var cut int64
+	for nodeIdx := range wg.numNodes() {
+		for i := wg.ptr[nodeIdx]; i < wg.ptr[nodeIdx+1]; i++ {
+			if parts[wg.adj[i]] != parts[nodeIdx] {
+				cut += wg.adjWeight[i]
+			}
+		}
+	}
+	return cut / 2
+}
+
+// coarsen collapses a heavy-edge matching: each node is matched with its unmatched neighbor with the heaviest edge.
+// It returns the coarse graph and the mapping from the nodes of wg to the coarse nodes.
+func (wg *weightedGraph) coarsen(rng *rand.Rand) (*weightedGraph, []int32) {
+	numNodes := wg.numNodes()
+	match := make([]int32, numNodes)
+	for i := range match {
+		match[i] = -1
+	}
+	for _, nodeIdx := range rng.Perm(numNodes) {
+		if match[nodeIdx] >= 0 {
+			continue
+		}
+		best, bestWeight := int32(nodeIdx), int64(-1)
+		for i := wg.ptr[nodeIdx]; i < wg.ptr[nodeIdx+1]; i++ {
+			if neighbor := wg.adj[i]; match[neighbor] < 0 && wg.adjWeight[i] > bestWeight {
+				best, bestWeight = neighbor, wg.adjWeight[i]
+			}
+		}
+		match[nodeIdx], match[best] = best, int32(nodeIdx)
+	}
+
+	mapping := make([]int32, numNodes)
+	for i := range mapping {
+		mapping[i] = -1
+	}
+	var members [][2]int32
+	for nodeIdx := range numNodes {
+		if mapping[nodeIdx] >= 0 {
+			continue
+		}
+		coarseIdx := int32(len(members))
+		mapping[nodeIdx], mapping[match[nodeIdx]] = coarseIdx, coarseIdx
+		members = append(members, [2]int32{int32(nodeIdx), match[nodeIdx]})
+	}
+
+	numCoarse := len(members)
+	coarse := &weightedGraph{
+		ptr:        make([]int32, numCoarse+1),
+		nodeWeight: make([]int64, numCoarse),
+	}
+	// position[c] is the index in coarse.adj of the edge to coarse node c in the current row, if >= rowStart.
+	position := make([]int32, numCoarse)
+	for i := range position {
+		position[i] = -1
+	}
+	for coarseIdx, pair := range members {
+		rowStart := int32(len(coarse.adj))
+		for k, nodeIdx := range pair {
+			if k == 1 && nodeIdx == pair[0] {
+				break
+			}
+			coarse.nodeWeight[coarseIdx] += wg.nodeWeight[nodeIdx]
+			for i := wg.ptr[nodeIdx]; i < wg.ptr[nodeIdx+1]; i++ {
+				neighbor := mapping[wg.adj[i]]
+				if int(neighbor) == coarseIdx {
+					continue
+				}
+				if pos := position[neighbor]; pos >= rowStart {
+					coarse.adjWeight[pos] += wg.adjWeight[i]
+					continue
+				}
+				position[neighbor] = int32(len(coarse.adj))
+				coarse.adj = append(coarse.adj, neighbor)
+				coarse.adjWeight = append(coarse.adjWeight, wg.adjWeight[i])
+			}
+		}
+		coarse.ptr[coarseIdx+1] = int32(len(coarse.adj))
+	}
+	return coarse, mapping
+}
+
+// growPartition creates an initial partition by greedy region growing: each part is grown by breadth-first
+// search from a random node, until it reaches its share of the total weight. The last part takes the rest.
+func (wg *weightedGraph) growPartition(rng *rand.Rand, numParts int) []int32 {
+	numNodes := wg.numNodes()
+	parts := make([]int32, numNodes)
+	for i := range parts {
+		parts[i] = -1
+	}
+	order := rng.Perm(numNodes)
+	nextSeed := 0
+	remaining := wg.totalWeight()
+	for part := range numParts - 1 {
+		target := remaining / int64(numParts-part)
+		var weight int64
+		var queue []int32
+		for weight < target {
+			if len(queue) == 0 {
+				// Start (or restart, for disconnected graphs) from a random unassigned node.
+				for nextSeed < numNodes && parts[order[nextSeed]] >= 0 {
+					nextSeed++
+				}
+				if nextSeed == numNodes {
+					break
+				}
+				seed := int32(order[nextSeed])
+				parts[seed] = int32(part)
+				weight += wg.nodeWeight[seed]
+				queue = append(queue, seed)
+				continue
+			}
+			nodeIdx := queue[0]
+			queue = queue[1:]
+			for i := wg.ptr[nodeIdx]; i < wg.ptr[nodeIdx+1] && weight < target; i++ {
+				if neighbor := wg.adj[i]; parts[neighbor] < 0 {
+					parts[neighbor] = int32(part)
+					weight += wg.nodeWeight[neighbor]
+					queue = append(queue, neighbor)
+				}
+			}
+		}
+		remaining -= weight
+	}
+	for i := range parts {
+		if parts[i] < 0 {
+			parts[i] = int32(numParts - 1)
+		}
+	}
+	return parts
+}
+
+// refine greedily moves nodes to the neighboring part they are most connected to, if it reduces the edge-cut
+// without exceeding maxWeight, or if it reduces the weight of an overweight part. It runs up to numPasses passes,
+// stopping early if no node moves.
+func (wg *weightedGraph) refine(rng *rand.Rand, parts []int32, numParts int, maxWeight int64, numPasses int) {
+	numNodes := wg.numNodes()
+	partWeight := make([]int64, numParts)
+	for nodeIdx, part := range parts {
+		partWeight[part] += wg.nodeWeight[nodeIdx]
+	}
+	connectivity := make([]int64, numParts)
+	var touched []int32
+	for range numPasses {
+		var moved int
+		for _, nodeIdx := range rng.Perm(numNodes) {
+			from := parts[nodeIdx]
+			weight := wg.nodeWeight[nodeIdx]
+			if partWeight[from] == weight {
+				// Never empty a part.
+				continue
+			}
+			touched = touched[:0]
+			for i := wg.ptr[nodeIdx]; i < wg.ptr[nodeIdx+1]; i++ {
+				part := parts[wg.adj[i]]
+				if connectivity[part] == 0 {
+					touched = append(touched, part)
+				}
+				connectivity[part] += wg.adjWeight[i]
+			}
+			overweight := partWeight[from] > maxWeight
+			best, bestGain := int32(-1), int64(0)
+			for _, part := range touched {
+				if part == from || partWeight[part]+weight > maxWeight {
+					continue
+				}
+				gain := connectivity[part] - connectivity[from]
+				improvesBalance := partWeight[from]-partWeight[part] > weight
+				if best < 0 && (gain > 0 || (gain == 0 && improvesBalance) || overweight) || best >= 0 && gain > bestGain {
+					best, bestGain = part, gain
+				}
+			}
+			if best < 0 && overweight {
+				// No neighboring part has room: move to the lightest part.
+				for part := range int32(numParts) {
+					if part != from && (best < 0 || partWeight[part] < partWeight[best]) {
+						best = part
+					}
+				}
+				if partWeight[best]+weight > maxWeight {
+					best = -1
+				}
+			}
+			for _, part := range touched {
+				connectivity[part] = 0
+			}
+			if best >= 0 {
+				parts[nodeIdx] = best
+				partWeight[from] -= weight
+				partWeight[best] += weight
+				moved++
+			}
+		}
+		if moved == 0 {
+			break
+		}
+	}
+}
+
+// ClusterLoader yields the mini-batches of Cluster-GCN: the graph is split into clusters (e.g. with Partition),
+// and each mini-batch is the subgraph induced by the union of a few random clusters, including the edges between
+// them.
+//
+// It is not safe for concurrent use.
+type ClusterLoader struct {
+	g                *Graph
+	clusters         [][]int32
+	clustersPerBatch int
+	rng              *rand.Rand
+	order            []int
+	next             int
+	epoch            int
+}
+
+// NewClusterLoader creates a ClusterLoader for the graph, given the cluster of each node shaped [NumNodes]Int32
+// (as returned by Partition) and the number of clusters combined in each mini-batch.
+func NewClusterLoader(g *Graph, clusters *tensors.Tensor, clustersPerBatch int, seed uint64) (*ClusterLoader, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkLeadingDim("clusters", clusters, g.NumNodes); err != nil {
+		return nil, err
+	}
+	if clusters.Shape().Rank() != 1 {
+		return nil, fmt.Errorf("clusters must be shaped [NumNodes=%d], got %s", g.NumNodes, clusters.Shape())
+	}
+	l := &ClusterLoader{
+		g:                g,
+		clustersPerBatch: clustersPerBatch,
+		rng:              rand.New(rand.NewPCG(seed, 0)),
+	}
+	var err error
+	tensors.ConstFlatData(clusters, func(flat []int32) {
+		for nodeIdx, cluster := range flat {
+			if cluster < 0 {
+				err = fmt.Errorf("invalid negative cluster %d for node %d", cluster, nodeIdx)
+				return
+			}
+			for int(cluster) >= len(l.clusters) {
+				l.clusters = append(l.clusters, nil)
+			}
+			l.clusters[cluster] = append(l.clusters[cluster], int32(nodeIdx))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if clustersPerBatch <= 0 || clustersPerBatch > len(l.clusters) {
+		return nil, fmt.Errorf("invalid number of clusters per batch %d for %d clusters", clustersPerBatch, len(l.clusters))
+	}
+	l.order = l.rng.Perm(len(l.clusters))
+	return l, nil
+}
+
+// NumBatches returns the number of mini-batches per epoch.
+func (l *ClusterLoader) NumBatches() int {
+	return (len(l.clusters) + l.clustersPerBatch - 1) / l.clustersPerBatch
+}
+
+// Epoch returns the current epoch, starting at 0. It is incremented after the last mini-batch of each epoch.
+func (l *ClusterLoader) Epoch() int {
+	return l.epoch
+}
+
+// Next returns the next mini-batch: the subgraph induced by the nodes of the next clustersPerBatch clusters, and the
+// original indices of its nodes (in increasing order). The node and edge features are sliced as in
+// Graph.InducedSubgraph.
+//
+// The clusters are shuffled at the start of each epoch, and the last mini-batch of an epoch may have fewer clusters.
+// It returns an error if the mini-batch has no edges.
+func (l *ClusterLoader) Next() (subgraph *Graph, nodeIDs []int32, err error) {
+	end := min(l.next+l.clustersPerBatch, len(l.order))
+	nodeMask := make([]bool, l.g.NumNodes)
+	for _, cluster := range l.order[l.next:end] {
+		for _, nodeIdx := range l.clusters[cluster] {
+			nodeMask[nodeIdx] = true
+		}
+	}
+	l.next = end
+	if l.next == len(l.order) {
+		l.next = 0
+		l.epoch++
+		l.rng.Shuffle(len(l.order), func(i, j int) { l.order[i], l.order[j] = l.order[j], l.order[i] })
+	}
+	s, err := inducedSubgraph(l.g.Edges, nodeMask)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l.g.sliceSubgraph(s), s.nodeIDs, nil
+}
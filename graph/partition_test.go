@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+// communityEdges returns a graph with numCommunities groups of communitySize consecutive nodes, densely connected
+// inside the groups and with numBridges random edges across groups.
+func communityEdges(rng *rand.Rand, numCommunities, communitySize, edgesPerNode, numBridges int) *tensors.Tensor {
+	numNodes := numCommunities * communitySize
+	var sources, targets []int32
+	for nodeIdx := range numNodes {
+		base := nodeIdx / communitySize * communitySize
+		for range edgesPerNode {
+			sources = append(sources, int32(nodeIdx))
+			targets = append(targets, int32(base+rng.IntN(communitySize)))
+		}
+	}
+	for range numBridges {
+		sources = append(sources, int32(rng.IntN(numNodes)))
+		targets = append(targets, int32(rng.IntN(numNodes)))
+	}
+	return tensors.FromValue([][]int32{sources, targets})
+}
+
+// countCut returns the number of edges between different parts.
+func countCut(edges *tensors.Tensor, parts []int32) int {
+	var cut int
+	tensors.ConstFlatData(edges, func(flat []int32) {
+		numEdges := len(flat) / 2
+		for i := range numEdges {
+			if parts[flat[i]] != parts[flat[numEdges+i]] {
+				cut++
+			}
+		}
+	})
+	return cut
+}
+
+func TestPartition(t *testing.T) {
+	rng := rand.New(rand.NewPCG(42, 0))
+	const numCommunities, communitySize = 8, 100
+	numNodes := numCommunities * communitySize
+	edges := communityEdges(rng, numCommunities, communitySize, 4, 50)
+	partsT, err := Partition(edges, numNodes, numCommunities).Seed(1).Done()
+	require.NoError(t, err)
+	parts := partsT.Value().([]int32)
+	require.Len(t, parts, numNodes)
+
+	sizes := make([]int, numCommunities)
+	for _, part := range parts {
+		require.GreaterOrEqual(t, part, int32(0))
+		require.Less(t, part, int32(numCommunities))
+		sizes[part]++
+	}
+	for part, size := range sizes {
+		require.LessOrEqual(t, size, int(1.05*communitySize)+1, "part %d has %d nodes", part, size)
+		require.Greater(t, size, 0)
+	}
+	// The communities should be (mostly) recovered: the edge-cut is close to the number of bridges, and much lower
+	// than a random assignment (~7/8 of the edges).
+	cut := countCut(edges, parts)
+	require.Less(t, cut, 200, "edge-cut %d", cut)
+
+	// Deterministic given the seed.
+	again, err := Partition(edges, numNodes, numCommunities).Seed(1).Done()
+	require.NoError(t, err)
+	require.Equal(t, parts, again.Value())
+
+	// One part and disconnected graphs.
+	partsT, err = Partition(edges, numNodes, 1).Done()
+	require.NoError(t, err)
+	require.Equal(t, make([]int32, numNodes), partsT.Value())
+	partsT, err = Partition(tensors.FromValue([][]int32{{0, 2}, {1, 3}}), 6, 3).Done()
+	require.NoError(t, err)
+	sizes = make([]int, 3)
+	for _, part := range partsT.Value().([]int32) {
+		sizes[part]++
+	}
+	require.Equal(t, []int{2, 2, 2}, sizes)
+
+	_, err = Partition(edges, numNodes, 0).Done()
+	require.Error(t, err)
+	_, err = Partition(edges, 4, 8).Done()
+	require.Error(t, err)
+	_, err = Partition(edges, 100, 4).Done()
+	require.Error(t, err, "edges out of range")
+}
+
+func TestClusterLoader(t *testing.T) {
+	// Path 0 - 1 - 2 - 3 - 4 - 5, with clusters {0, 1}, {2, 3} and {4, 5}.
+	edges := tensors.FromValue([][]int32{{0, 1, 2, 3, 4}, {1, 2, 3, 4, 5}})
+	g := New(6, edges)
+	g.NodeFeatures = tensors.FromValue([]float32{0, 1, 2, 3, 4, 5})
+	g.EdgeFeatures = tensors.FromValue([]int32{10, 11, 12, 13, 14})
+	clusters := tensors.FromValue([]int32{0, 0, 1, 1, 2, 2})
+
+	loader, err := NewClusterLoader(g, clusters, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, loader.NumBatches())
+	for epoch := range 3 {
+		seen := make([]int, 6)
+		for range loader.NumBatches() {
+			require.Equal(t, epoch, loader.Epoch())
+			subgraph, nodeIDs, err := loader.Next()
+			require.NoError(t, err)
+			require.NoError(t, subgraph.Validate())
+			require.Equal(t, len(nodeIDs), subgraph.NumNodes)
+			features := subgraph.NodeFeatures.Value().([]float32)
+			for i, nodeIdx := range nodeIDs {
+				seen[nodeIdx]++
+				require.Equal(t, float32(nodeIdx), features[i])
+			}
+			// Edges between the selected clusters are kept.
+			var numPathEdges int
+			for i := 1; i < len(nodeIDs); i++ {
+				if nodeIDs[i] == nodeIDs[i-1]+1 {
+					numPathEdges++
+				}
+			}
+			require.Equal(t, numPathEdges, subgraph.NumEdges())
+		}
+		require.Equal(t, []int{1, 1, 1, 1, 1, 1}, seen, "epoch %d", epoch)
+	}
+	require.Equal(t, 3, loader.Epoch())
+
+	_, err = NewClusterLoader(g, clusters, 4, 1)
+	require.Error(t, err)
+	_, err = NewClusterLoader(g, tensors.FromValue([]int32{0, 0, 1}), 1, 1)
+	require.Error(t, err)
+	_, err = NewClusterLoader(g, tensors.FromValue([]int32{0, 0, 1, 1, -1, 2}), 1, 1)
+	require.Error(t, err)
+}
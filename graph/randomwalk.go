@@ -0,0 +1,296 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// RandomWalksConfig is created with RandomWalks and once fully configured, can be executed
+// with Done.
+type RandomWalksConfig struct {
+	adj          *Adjacency
+	walkLength   int
+	walksPerNode int
+	startNodes   []int32
+	p, q         float64
+	seed         uint64
+	numWorkers   int
+}
+
+// RandomWalks generates random walks following the outgoing edges of the graph, as used by DeepWalk (uniform walks)
+// and node2vec (biased walks, see RandomWalksConfig.Node2Vec) to train shallow node embeddings.
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - adj: the Adjacency of the graph, see NewAdjacency and Graph.Adjacency.
+//   - walkLength: number of nodes in each walk, including the start node.
+//
+// It returns a configuration that can be optionally configured. Call RandomWalksConfig.Done to perform
+// the operation.
+func RandomWalks(adj *Adjacency, walkLength int) *RandomWalksConfig {
+	return &RandomWalksConfig{
+		adj:          adj,
+		walkLength:   walkLength,
+		walksPerNode: 1,
+		p:            1,
+		q:            1,
+		numWorkers:   runtime.NumCPU(),
+	}
+}
+
+// WalksPerNode sets the number of walks started from each start node. Default is 1.
+func (c *RandomWalksConfig) WalksPerNode(walksPerNode int) *RandomWalksConfig {
+	c.walksPerNode = walksPerNode
+	return c
+}
+
+// StartNodes sets the nodes from where to start the walks. Default is all nodes of the graph.
+func (c *RandomWalksConfig) StartNodes(startNodes []int32) *RandomWalksConfig {
+	c.startNodes = startNodes
+	return c
+}
+
+// Node2Vec biases the walks with node2vec's return parameter p and in-out parameter q: coming from node t to node v,
+// the next node x is chosen with unnormalized probability 1/p if x == t, 1 if x is a neighbor of t, and 1/q
+// otherwise. Default is p = q = 1, which are uniform (DeepWalk) walks.
+//
+// It is implemented with rejection sampling, so it doesn't require any pre-computed transition tables.
+func (c *RandomWalksConfig) Node2Vec(p, q float64) *RandomWalksConfig {
+	c.p, c.q = p, q
+	return c
+}
+
+// Seed sets the seed of the random number generator. Default is 0.
+func (c *RandomWalksConfig) Seed(seed uint64) *RandomWalksConfig {
+	c.seed = seed
+	return c
+}
+
+// NumWorkers sets the number of goroutines used to generate the walks. Default is runtime.NumCPU().
+// The walks don't depend on the number of workers.
+func (c *RandomWalksConfig) NumWorkers(numWorkers int) *RandomWalksConfig {
+	c.numWorkers = numWorkers
+	return c
+}
+
+// Done generates the walks as configured.
+//
+// It returns a tensor shaped [numWalks, walkLength]Int32, with numWalks = numStartNodes * walksPerNode: walk
+// i starts at startNodes[i / walksPerNode]. Walks that reach a node without outgoing edges are padded with -1.
+func (c *RandomWalksConfig) Done() (*tensors.Tensor, error) {
+	if c.adj == nil {
+		return nil, fmt.Errorf("RandomWalks requires an Adjacency")
+	}
+	if c.walkLength <= 0 || c.walksPerNode <= 0 || c.numWorkers <= 0 {
+		return nil, fmt.Errorf("walkLength (%d), walksPerNode (%d) and numWorkers (%d) must be positive",
+			c.walkLength, c.walksPerNode, c.numWorkers)
+	}
+	if c.p <= 0 || c.q <= 0 {
+		return nil, fmt.Errorf("node2vec parameters p (%g) and q (%g) must be positive", c.p, c.q)
+	}
+	numNodes := c.adj.NumNodes()
+	startNodes := c.startNodes
+	if startNodes == nil {
+		startNodes = rangeIndices(0, int32(numNodes))
+	}
+	for _, node := range startNodes {
+		if node < 0 || int(node) >= numNodes {
+			return nil, fmt.Errorf("start node %d out of range, there are %d nodes", node, numNodes)
+		}
+	}
+	if len(startNodes) == 0 {
+		return nil, fmt.Errorf("no start nodes given")
+	}
+
+	numWalks := len(startNodes) * c.walksPerNode
+	walks := tensors.FromShape(shapes.Make(dtypes.Int32, numWalks, c.walkLength))
+	tensors.MutableFlatData(walks, func(flat []int32) {
+		numWorkers := min(c.numWorkers, numWalks)
+		var wg sync.WaitGroup
+		for worker := range numWorkers {
+			start, end := worker*numWalks/numWorkers, (worker+1)*numWalks/numWorkers
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pcg := &rand.PCG{}
+				rng := rand.New(pcg)
+				for walkIdx := start; walkIdx < end; walkIdx++ {
+					pcg.Seed(c.seed, uint64(walkIdx))
+					c.walk(rng, startNodes[walkIdx/c.walksPerNode], flat[walkIdx*c.walkLength:(walkIdx+1)*c.walkLength])
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	return walks, nil
+}
+
+// walk fills one walk starting at node.
+func (c *RandomWalksConfig) walk(rng *rand.Rand, node int32, walk []int32) {
+	walk[0] = node
+	uniform := c.p == 1 && c.q == 1
+	maxWeight := max(1/c.p, 1, 1/c.q)
+	for step := 1; step < len(walk); step++ {
+		neighbors := c.adj.Neighbors(int(node))
+		if len(neighbors) == 0 {
+			for i := step; i < len(walk); i++ {
+				walk[i] = -1
+			}
+			return
+		}
+		if uniform || step == 1 {
+			node = neighbors[rng.IntN(len(neighbors))]
+		} else {
+			previous := walk[step-2]
+			previousNeighbors := c.adj.Neighbors(int(previous))
+			for {
+				candidate := neighbors[rng.IntN(len(neighbors))]
+				weight := 1 / c.q
+				if candidate == previous {
+					weight = 1 / c.p
+				} else if _, found := slices.BinarySearch(previousNeighbors, candidate); found {
+					weight = 1
+				}
+				if rng.Float64()*maxWeight < weight {
+					node = candidate
+					break
+				}
+			}
+		}
+		walk[step] = node
+	}
+}
+
+// SkipGramConfig is created with SkipGram and once fully configured, can be executed
+// with Done.
+type SkipGramConfig struct {
+	walks        *tensors.Tensor
+	windowSize   int
+	numNegatives int
+	numNodes     int
+	seed         uint64
+}
+
+// SkipGram generates the skip-gram (center, context) training pairs from random walks (see RandomWalks), as
+// used by DeepWalk and node2vec: for each node in a walk, every other node within windowSize steps is a context.
+//
+// Optionally, negative pairs are added with SkipGramConfig.NegativeSamples.
+//
+// Args:
+//   - walks: shaped [numWalks, walkLength]Int32. Negative values (the padding of walks that ended early) are ignored.
+//   - windowSize: maximum distance between the center and the context nodes in the walk.
+//
+// It returns a configuration that can be optionally configured. Call SkipGramConfig.Done to perform
+// the operation.
+func SkipGram(walks *tensors.Tensor, windowSize int) *SkipGramConfig {
+	return &SkipGramConfig{
+		walks:      walks,
+		windowSize: windowSize,
+	}
+}
+
+// NegativeSamples adds numNegatives negative pairs per positive pair, with the same center and a random context
+// node. As in word2vec, the random nodes are drawn with probability proportional to their frequency in the walks
+// raised to 3/4. numNodes is the number of nodes of the graph.
+func (c *SkipGramConfig) NegativeSamples(numNegatives, numNodes int) *SkipGramConfig {
+	c.numNegatives = numNegatives
+	c.numNodes = numNodes
+	return c
+}
+
+// Seed sets the seed of the random number generator used for the negative samples. Default is 0.
+func (c *SkipGramConfig) Seed(seed uint64) *SkipGramConfig {
+	c.seed = seed
+	return c
+}
+
+// Done generates the skip-gram pairs as configured.
+//
+// It returns the pairs shaped [numPairs, 2]Int32 with the (center, context) nodes, and the labels shaped
+// [numPairs]Float32, 1 for positive pairs and 0 for negative ones. Each positive pair is followed by its negative
+// pairs.
+func (c *SkipGramConfig) Done() (pairs, labels *tensors.Tensor, err error) {
+	if c.walks.Shape().Rank() != 2 || c.walks.DType() != dtypes.Int32 {
+		return nil, nil, fmt.Errorf("walks must be shaped [numWalks, walkLength]Int32, got %s", c.walks.Shape())
+	}
+	if c.windowSize <= 0 || c.numNegatives < 0 {
+		return nil, nil, fmt.Errorf("invalid windowSize (%d) or number of negatives (%d)", c.windowSize, c.numNegatives)
+	}
+	if c.numNegatives > 0 && c.numNodes <= 0 {
+		return nil, nil, fmt.Errorf("invalid number of nodes %d for negative samples", c.numNodes)
+	}
+	walkLength := c.walks.Shape().Dimensions[1]
+	var flatPairs []int32
+	var flatLabels []float32
+	tensors.ConstFlatData(c.walks, func(flat []int32) {
+		var cumulative []float64
+		if c.numNegatives > 0 {
+			cumulative, err = negativeDistribution(flat, c.numNodes)
+			if err != nil {
+				return
+			}
+		}
+		rng := rand.New(rand.NewPCG(c.seed, 0))
+		for start := 0; start < len(flat); start += walkLength {
+			walk := flat[start : start+walkLength]
+			for i, center := range walk {
+				if center < 0 {
+					continue
+				}
+				for j := max(i-c.windowSize, 0); j <= min(i+c.windowSize, walkLength-1); j++ {
+					if j == i || walk[j] < 0 {
+						continue
+					}
+					flatPairs = append(flatPairs, center, walk[j])
+					flatLabels = append(flatLabels, 1)
+					for range c.numNegatives {
+						// 1-Float64() is in (0, 1], so nodes with zero frequency are never drawn.
+						negative := sort.SearchFloat64s(cumulative, (1-rng.Float64())*cumulative[len(cumulative)-1])
+						flatPairs = append(flatPairs, center, int32(negative))
+						flatLabels = append(flatLabels, 0)
+					}
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(flatLabels) == 0 {
+		return nil, nil, fmt.Errorf("no skip-gram pairs found in the walks")
+	}
+	return tensors.FromFlatDataAndDimensions(flatPairs, len(flatLabels), 2), tensors.FromValue(flatLabels), nil
+}
+
+// negativeDistribution returns the cumulative distribution of the frequency of the nodes in the walks raised to 3/4.
+func negativeDistribution(walks []int32, numNodes int) ([]float64, error) {
+	counts := make([]float64, numNodes)
+	for _, node := range walks {
+		if node < 0 {
+			continue
+		}
+		if int(node) >= numNodes {
+			return nil, fmt.Errorf("node %d in the walks out of range, there are %d nodes", node, numNodes)
+		}
+		counts[node]++
+	}
+	var total float64
+	for node, count := range counts {
+		total += math.Pow(count, 0.75)
+		counts[node] = total
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no nodes in the walks")
+	}
+	return counts, nil
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+// cycleEdges returns the edges of an undirected cycle with numNodes nodes.
+func cycleEdges(numNodes int) *tensors.Tensor {
+	var sources, targets []int32
+	for i := range numNodes {
+		next := int32((i + 1) % numNodes)
+		sources = append(sources, int32(i), next)
+		targets = append(targets, next, int32(i))
+	}
+	return tensors.FromValue([][]int32{sources, targets})
+}
+
+func TestRandomWalks(t *testing.T) {
+	// 0 -> 1 -> 2 -> 0, 2 -> 3; node 3 is a dead end.
+	adj, err := NewAdjacency(tensors.FromValue([][]int32{{0, 1, 2, 2}, {1, 2, 0, 3}}), 4)
+	require.NoError(t, err)
+	walksT, err := RandomWalks(adj, 6).WalksPerNode(3).Seed(1).NumWorkers(1).Done()
+	require.NoError(t, err)
+	require.Equal(t, []int{12, 6}, walksT.Shape().Dimensions)
+	walks := walksT.Value().([][]int32)
+	for i, walk := range walks {
+		require.Equal(t, int32(i/3), walk[0])
+		for step := 1; step < len(walk); step++ {
+			if walk[step-1] == 3 || walk[step-1] == -1 {
+				require.Equal(t, int32(-1), walk[step])
+				continue
+			}
+			_, found := slices.BinarySearch(adj.Neighbors(int(walk[step-1])), walk[step])
+			require.True(t, found, "walk %v has an invalid step", walk)
+		}
+	}
+
+	// Reproducible by seed, independent of the number of workers.
+	parallel, err := RandomWalks(adj, 6).WalksPerNode(3).Seed(1).NumWorkers(4).Done()
+	require.NoError(t, err)
+	require.Equal(t, walks, parallel.Value())
+
+	// Start nodes.
+	walksT, err = RandomWalks(adj, 2).StartNodes([]int32{3, 0}).Done()
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{3, -1}, {0, 1}}, walksT.Value())
+
+	_, err = RandomWalks(adj, 0).Done()
+	require.Error(t, err)
+	_, err = RandomWalks(adj, 3).StartNodes([]int32{4}).Done()
+	require.Error(t, err)
+	_, err = RandomWalks(adj, 3).Node2Vec(0, 1).Done()
+	require.Error(t, err)
+}
+
+func TestNode2Vec(t *testing.T) {
+	adj, err := NewAdjacency(cycleEdges(8), 8)
+	require.NoError(t, err)
+
+	// A small p makes walks return to the previous node: they alternate between two nodes.
+	walksT, err := RandomWalks(adj, 20).Node2Vec(0.001, 1000).Done()
+	require.NoError(t, err)
+	var backtracks, steps int
+	for _, walk := range walksT.Value().([][]int32) {
+		for step := 2; step < len(walk); step++ {
+			steps++
+			if walk[step] == walk[step-2] {
+				backtracks++
+			}
+		}
+	}
+	require.Greater(t, backtracks, steps*95/100)
+
+	// A small q makes walks move away: they go around the cycle without backtracking.
+	walksT, err = RandomWalks(adj, 20).Node2Vec(1000, 0.001).Done()
+	require.NoError(t, err)
+	backtracks, steps = 0, 0
+	for _, walk := range walksT.Value().([][]int32) {
+		for step := 2; step < len(walk); step++ {
+			steps++
+			if walk[step] == walk[step-2] {
+				backtracks++
+			}
+		}
+	}
+	require.Less(t, backtracks, steps*5/100)
+}
+
+func TestSkipGram(t *testing.T) {
+	walks := tensors.FromValue([][]int32{{0, 1, 2}, {3, -1, -1}})
+	pairs, labels, err := SkipGram(walks, 1).Done()
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0, 1}, {1, 0}, {1, 2}, {2, 1}}, pairs.Value())
+	require.Equal(t, []float32{1, 1, 1, 1}, labels.Value())
+
+	pairs, labels, err = SkipGram(walks, 2).NegativeSamples(2, 5).Seed(3).Done()
+	require.NoError(t, err)
+	require.Equal(t, []int{18, 2}, pairs.Shape().Dimensions)
+	pairsValue, labelsValue := pairs.Value().([][]int32), labels.Value().([]float32)
+	for i := range labelsValue {
+		if i%3 == 0 {
+			require.Equal(t, float32(1), labelsValue[i])
+			continue
+		}
+		require.Equal(t, float32(0), labelsValue[i])
+		require.Equal(t, pairsValue[i-i%3][0], pairsValue[i][0])
+		// Node 4 never appears in the walks, so it is never drawn as a negative.
+		require.NotEqual(t, int32(4), pairsValue[i][1])
+	}
+
+	_, _, err = SkipGram(tensors.FromValue([][]int32{{0, -1}}), 1).Done()
+	require.Error(t, err)
+	_, _, err = SkipGram(walks, 1).NegativeSamples(1, 2).Done()
+	require.Error(t, err)
+}
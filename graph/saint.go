@@ -0,0 +1,369 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// SAINTMethod defines how a GraphSAINTSampler samples the nodes of the subgraphs.
+type SAINTMethod int
+
+const (
+	// SAINTNodes draws budget nodes (with replacement), each with probability proportional to its in-degree.
+	// Nodes without incoming edges are never drawn.
+	SAINTNodes SAINTMethod = iota
+
+	// SAINTEdges draws budget edges (with replacement), edge u->v with probability proportional to
+	// 1/deg(u) + 1/deg(v), where deg is the total (in plus out) degree, and takes both of their nodes.
+	SAINTEdges
+
+	// SAINTRandomWalks starts budget random walks from uniformly drawn root nodes, following the outgoing edges
+	// for walkLength steps, and takes all the visited nodes. Use ToUndirected to walk undirected graphs.
+	SAINTRandomWalks
+)
+
+// String implements fmt.Stringer.
+func (m SAINTMethod) String() string {
+	switch m {
+	case SAINTNodes:
+		return "SAINTNodes"
+	case SAINTEdges:
+		return "SAINTEdges"
+	case SAINTRandomWalks:
+		return "SAINTRandomWalks"
+	default:
+		return fmt.Sprintf("SAINTMethod(%d)", int(m))
+	}
+}
+
+// GraphSAINTConfig is created with NewGraphSAINTSampler and once fully configured, can be executed
+// with Done.
+type GraphSAINTConfig struct {
+	adj                *Adjacency
+	method             SAINTMethod
+	budget, walkLength int
+	numNormSamples     int
+	maxNorm            float32
+	seed               uint64
+	numWorkers         int
+}
+
+// NewGraphSAINTSampler creates a GraphSAINT sampler (Zeng et al., 2020): each mini-batch is the subgraph induced
+// by a random set of nodes, sampled with one of the SAINTMethod samplers, and the bias of the sampling is
+// corrected with normalization coefficients, pre-computed by counting how often each node and edge is sampled:
+//
+//   - The loss normalization of node v is numNormSamples / (C_v * numNodes), where C_v is the number of
+//     pre-computed subgraphs with v: weight the loss of each node by it, and sum.
+//   - The aggregation normalization of edge u->v is C_v / C_uv, where C_uv is the number of subgraphs with the
+//     edge: multiply the messages by it before the aggregation at the target v.
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - adj: the Adjacency of the graph, see NewAdjacency and Graph.Adjacency.
+//   - method: how to sample the nodes, see SAINTMethod.
+//   - budget: number of nodes (SAINTNodes), edges (SAINTEdges) or walk roots (SAINTRandomWalks) drawn per subgraph.
+//
+// It returns a configuration that can be optionally configured. Call GraphSAINTConfig.Done to create
+// the sampler, which pre-computes the normalization coefficients.
+func NewGraphSAINTSampler(adj *Adjacency, method SAINTMethod, budget int) *GraphSAINTConfig {
+	return &GraphSAINTConfig{
+		adj:            adj,
+		method:         method,
+		budget:         budget,
+		walkLength:     2,
+		numNormSamples: 50,
+		maxNorm:        1e4,
+		numWorkers:     runtime.NumCPU(),
+	}
+}
+
+// WalkLength sets the number of steps of each walk, for SAINTRandomWalks. Default is 2.
+func (c *GraphSAINTConfig) WalkLength(walkLength int) *GraphSAINTConfig {
+	c.walkLength = walkLength
+	return c
+}
+
+// NormalizationSamples sets the number of subgraphs sampled to pre-compute the normalization coefficients.
+// Default is 50. Larger values give more accurate coefficients, at the cost of a slower Done.
+func (c *GraphSAINTConfig) NormalizationSamples(numSamples int) *GraphSAINTConfig {
+	c.numNormSamples = numSamples
+	return c
+}
+
+// MaxNorm sets the maximum value of the aggregation normalization coefficients. Edges never sampled during the
+// pre-computation get this value. Default is 1e4.
+func (c *GraphSAINTConfig) MaxNorm(maxNorm float32) *GraphSAINTConfig {
+	c.maxNorm = maxNorm
+	return c
+}
+
+// Seed sets the seed of the random number generator. Default is 0.
+func (c *GraphSAINTConfig) Seed(seed uint64) *GraphSAINTConfig {
+	c.seed = seed
+	return c
+}
+
+// NumWorkers sets the number of goroutines used to pre-compute the normalization coefficients.
+// Default is runtime.NumCPU(). The coefficients don't depend on the number of workers.
+func (c *GraphSAINTConfig) NumWorkers(numWorkers int) *GraphSAINTConfig {
+	c.numWorkers = numWorkers
+	return c
+}
+
+// Done validates the configuration, pre-computes the normalization coefficients and returns the GraphSAINTSampler.
+func (c *GraphSAINTConfig) Done() (*GraphSAINTSampler, error) {
+	if c.adj == nil {
+		return nil, fmt.Errorf("GraphSAINT requires an Adjacency")
+	}
+	if c.method < SAINTNodes || c.method > SAINTRandomWalks {
+		return nil, fmt.Errorf("invalid GraphSAINT method %s", c.method)
+	}
+	if c.budget <= 0 || c.walkLength <= 0 || c.numNormSamples <= 0 || c.numWorkers <= 0 || c.maxNorm <= 0 {
+		return nil, fmt.Errorf("budget (%d), walkLength (%d), numNormSamples (%d), numWorkers (%d) and maxNorm (%g) "+
+			"must be positive", c.budget, c.walkLength, c.numNormSamples, c.numWorkers, c.maxNorm)
+	}
+	if c.adj.NumEdges() == 0 {
+		return nil, fmt.Errorf("GraphSAINT requires a graph with edges")
+	}
+	s := &GraphSAINTSampler{config: *c}
+	numNodes, numEdges := c.adj.NumNodes(), c.adj.NumEdges()
+	switch c.method {
+	case SAINTNodes:
+		// The target of each position of the CSC format: drawing a position uniformly draws its target with
+		// probability proportional to its in-degree.
+		s.targets = make([]int32, numEdges)
+		for node := range numNodes {
+			for pos := c.adj.In.Ptr[node]; pos < c.adj.In.Ptr[node+1]; pos++ {
+				s.targets[pos] = int32(node)
+			}
+		}
+	case SAINTEdges:
+		// The cumulative probability of each position of the CSR format.
+		outDegrees, inDegrees := c.adj.OutDegrees(), c.adj.InDegrees()
+		degree := func(node int32) float64 { return float64(outDegrees[node] + inDegrees[node]) }
+		s.sources = make([]int32, numEdges)
+		s.cumulative = make([]float64, numEdges)
+		var total float64
+		for node := range int32(numNodes) {
+			for pos := c.adj.Out.Ptr[node]; pos < c.adj.Out.Ptr[node+1]; pos++ {
+				s.sources[pos] = node
+				total += 1/degree(node) + 1/degree(c.adj.Out.Indices[pos])
+				s.cumulative[pos] = total
+			}
+		}
+	}
+	s.computeNorms()
+	return s, nil
+}
+
+// GraphSAINTSampler samples subgraphs with normalization coefficients, see NewGraphSAINTSampler.
+// It is safe for concurrent use.
+type GraphSAINTSampler struct {
+	config   GraphSAINTConfig
+	numCalls atomic.Uint64
+
+	// targets for SAINTNodes, sources and cumulative for SAINTEdges.
+	targets, sources []int32
+	cumulative       []float64
+
+	nodeNorm, edgeNorm []float32
+}
+
+// SAINTSubgraph is the result of GraphSAINTSampler.Sample.
+type SAINTSubgraph struct {
+	// NodeIDs maps the local node indices of the subgraph to the original node indices, in increasing order.
+	NodeIDs []int32
+
+	// Edges shaped [2, numSubEdges]Int32 with the edges induced by the sampled nodes, using local node indices,
+	// sorted by source. It is nil if the sampled nodes have no edges between them.
+	Edges *tensors.Tensor
+
+	// EdgeIDs are the indices of the edges in the original edges tensor, to gather the edge features.
+	EdgeIDs []int32
+
+	// NodeNorm shaped [numSubNodes]Float32 with the loss normalization of each node.
+	NodeNorm *tensors.Tensor
+
+	// EdgeNorm shaped [numSubEdges]Float32 with the aggregation normalization of each edge. It is nil if Edges is nil.
+	EdgeNorm *tensors.Tensor
+}
+
+// NodeNorm returns the loss normalization coefficients of all the nodes of the graph, shaped [numNodes]Float32.
+func (s *GraphSAINTSampler) NodeNorm() *tensors.Tensor {
+	return tensors.FromValue(slices.Clone(s.nodeNorm))
+}
+
+// EdgeNorm returns the aggregation normalization coefficients of all the edges of the graph, in the order of the
+// original edges tensor, shaped [numEdges]Float32.
+func (s *GraphSAINTSampler) EdgeNorm() *tensors.Tensor {
+	return tensors.FromValue(slices.Clone(s.edgeNorm))
+}
+
+// Sample samples one subgraph.
+//
+// Each call uses a different random stream, derived from the configured seed and the number of previous calls,
+// so a sequence of calls is reproducible.
+func (s *GraphSAINTSampler) Sample() (*SAINTSubgraph, error) {
+	callIdx := s.numCalls.Add(1) - 1
+	// The streams of the pre-computation use the first sequences, see computeNorms.
+	rng := rand.New(rand.NewPCG(s.config.seed, 1<<63|callIdx))
+	nodeIDs, edgePositions := s.sample(rng, make([]bool, s.config.adj.NumNodes()))
+
+	csr := s.config.adj.Out
+	subgraph := &SAINTSubgraph{
+		NodeIDs:  nodeIDs,
+		NodeNorm: tensors.FromValue(gatherNorms(s.nodeNorm, nodeIDs)),
+	}
+	if len(edgePositions) == 0 {
+		return subgraph, nil
+	}
+	localIdx := make(map[int32]int32, len(nodeIDs))
+	for local, node := range nodeIDs {
+		localIdx[node] = int32(local)
+	}
+	sources := make([]int32, len(edgePositions))
+	targets := make([]int32, len(edgePositions))
+	subgraph.EdgeIDs = make([]int32, len(edgePositions))
+	var nodeIdx int
+	for i, pos := range edgePositions {
+		for csr.Ptr[nodeIDs[nodeIdx]+1] <= pos {
+			nodeIdx++
+		}
+		sources[i] = int32(nodeIdx)
+		targets[i] = localIdx[csr.Indices[pos]]
+		subgraph.EdgeIDs[i] = csr.Permutation[pos]
+	}
+	subgraph.Edges = edgesFromSlices(sources, targets)
+	subgraph.EdgeNorm = tensors.FromValue(gatherNorms(s.edgeNorm, subgraph.EdgeIDs))
+	return subgraph, nil
+}
+
+// sample draws the nodes of one subgraph, and returns them in increasing order, along with the positions in the CSR
+// format of the induced edges, also in increasing order. nodeMask must be all false, and is left all false.
+func (s *GraphSAINTSampler) sample(rng *rand.Rand, nodeMask []bool) (nodeIDs, edgePositions []int32) {
+	c := &s.config
+	add := func(node int32) {
+		if !nodeMask[node] {
+			nodeMask[node] = true
+			nodeIDs = append(nodeIDs, node)
+		}
+	}
+	switch c.method {
+	case SAINTNodes:
+		for range c.budget {
+			add(s.targets[rng.IntN(len(s.targets))])
+		}
+	case SAINTEdges:
+		total := s.cumulative[len(s.cumulative)-1]
+		for range c.budget {
+			// 1-Float64() is in (0, 1], so the first edge is drawn with the right probability.
+			pos := sort.SearchFloat64s(s.cumulative, (1-rng.Float64())*total)
+			add(s.sources[pos])
+			add(c.adj.Out.Indices[pos])
+		}
+	case SAINTRandomWalks:
+		numNodes := c.adj.NumNodes()
+		for range c.budget {
+			node := int32(rng.IntN(numNodes))
+			add(node)
+			for range c.walkLength {
+				neighbors := c.adj.Neighbors(int(node))
+				if len(neighbors) == 0 {
+					break
+				}
+				node = neighbors[rng.IntN(len(neighbors))]
+				add(node)
+			}
+		}
+	}
+	slices.Sort(nodeIDs)
+	csr := c.adj.Out
+	for _, node := range nodeIDs {
+		for pos := csr.Ptr[node]; pos < csr.Ptr[node+1]; pos++ {
+			if nodeMask[csr.Indices[pos]] {
+				edgePositions = append(edgePositions, pos)
+			}
+		}
+	}
+	for _, node := range nodeIDs {
+		nodeMask[node] = false
+	}
+	return nodeIDs, edgePositions
+}
+
+// computeNorms samples numNormSamples subgraphs in parallel, and computes the normalization coefficients from the
+// number of times each node and edge was sampled.
+func (s *GraphSAINTSampler) computeNorms() {
+	c := &s.config
+	numNodes, numEdges := c.adj.NumNodes(), c.adj.NumEdges()
+	numWorkers := min(c.numWorkers, c.numNormSamples)
+	nodeCounts := make([][]int32, numWorkers)
+	edgeCounts := make([][]int32, numWorkers)
+	var wg sync.WaitGroup
+	for worker := range numWorkers {
+		start, end := worker*c.numNormSamples/numWorkers, (worker+1)*c.numNormSamples/numWorkers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodeCount, edgeCount := make([]int32, numNodes), make([]int32, numEdges)
+			nodeMask := make([]bool, numNodes)
+			pcg := &rand.PCG{}
+			rng := rand.New(pcg)
+			for sampleIdx := start; sampleIdx < end; sampleIdx++ {
+				pcg.Seed(c.seed, uint64(sampleIdx))
+				nodeIDs, edgePositions := s.sample(rng, nodeMask)
+				for _, node := range nodeIDs {
+					nodeCount[node]++
+				}
+				for _, pos := range edgePositions {
+					edgeCount[pos]++
+				}
+			}
+			nodeCounts[worker], edgeCounts[worker] = nodeCount, edgeCount
+		}()
+	}
+	wg.Wait()
+	for worker := 1; worker < numWorkers; worker++ {
+		for node, count := range nodeCounts[worker] {
+			nodeCounts[0][node] += count
+		}
+		for pos, count := range edgeCounts[worker] {
+			edgeCounts[0][pos] += count
+		}
+	}
+	nodeCount, edgeCount := nodeCounts[0], edgeCounts[0]
+
+	s.nodeNorm = make([]float32, numNodes)
+	for node, count := range nodeCount {
+		// As in PyG, nodes never sampled are counted as sampled 0.1 times.
+		s.nodeNorm[node] = float32(float64(c.numNormSamples) / (max(float64(count), 0.1) * float64(numNodes)))
+	}
+	s.edgeNorm = make([]float32, numEdges)
+	csr := c.adj.Out
+	for source := range numNodes {
+		for pos := csr.Ptr[source]; pos < csr.Ptr[source+1]; pos++ {
+			norm := c.maxNorm
+			if count := edgeCount[pos]; count > 0 {
+				norm = min(float32(nodeCount[csr.Indices[pos]])/float32(count), c.maxNorm)
+			}
+			s.edgeNorm[csr.Permutation[pos]] = norm
+		}
+	}
+}
+
+// gatherNorms returns the normalization coefficients of the given indices.
+func gatherNorms(norms []float32, indices []int32) []float32 {
+	gathered := make([]float32, len(indices))
+	for i, idx := range indices {
+		gathered[i] = norms[idx]
+	}
+	return gathered
+}
@@ -0,0 +1,92 @@
+package graph
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphSAINTSampler(t *testing.T) {
+	const numNodes = 300
+	rng := rand.New(rand.NewPCG(42, 0))
+	edges := randomEdges(rng, 1_500, numNodes)
+	adj, err := NewAdjacency(edges, numNodes)
+	require.NoError(t, err)
+	sources, targets := New(numNodes, edges).Sources(), New(numNodes, edges).Targets()
+
+	for _, method := range []SAINTMethod{SAINTNodes, SAINTEdges, SAINTRandomWalks} {
+		sampler, err := NewGraphSAINTSampler(adj, method, 40).WalkLength(3).Seed(3).NumWorkers(1).Done()
+		require.NoError(t, err, "method=%s", method)
+		subgraph, err := sampler.Sample()
+		require.NoError(t, err, "method=%s", method)
+		require.True(t, slices.IsSorted(subgraph.NodeIDs), "method=%s", method)
+		require.Equal(t, []int{len(subgraph.NodeIDs)}, subgraph.NodeNorm.Shape().Dimensions)
+
+		// All the edges induced by the sampled nodes are returned.
+		selected := make(map[int32]bool)
+		for _, node := range subgraph.NodeIDs {
+			selected[node] = true
+		}
+		var numInduced int
+		for edgeIdx := range sources {
+			if selected[sources[edgeIdx]] && selected[targets[edgeIdx]] {
+				numInduced++
+			}
+		}
+		require.Len(t, subgraph.EdgeIDs, numInduced, "method=%s", method)
+		require.NotNil(t, subgraph.Edges, "method=%s", method)
+		subEdges := subgraph.Edges.Value().([][]int32)
+		edgeNorm := subgraph.EdgeNorm.Value().([]float32)
+		for i, edgeID := range subgraph.EdgeIDs {
+			require.Equal(t, sources[edgeID], subgraph.NodeIDs[subEdges[0][i]])
+			require.Equal(t, targets[edgeID], subgraph.NodeIDs[subEdges[1][i]])
+			require.Greater(t, edgeNorm[i], float32(0))
+		}
+
+		// Sequences of samples and the normalization are reproducible, and don't depend on the number of workers.
+		other, err := NewGraphSAINTSampler(adj, method, 40).WalkLength(3).Seed(3).NumWorkers(3).Done()
+		require.NoError(t, err)
+		require.Equal(t, sampler.NodeNorm().Value(), other.NodeNorm().Value(), "method=%s", method)
+		require.Equal(t, sampler.EdgeNorm().Value(), other.EdgeNorm().Value(), "method=%s", method)
+		otherSubgraph, err := other.Sample()
+		require.NoError(t, err)
+		require.Equal(t, subgraph.NodeIDs, otherSubgraph.NodeIDs, "method=%s", method)
+		nextSubgraph, err := sampler.Sample()
+		require.NoError(t, err)
+		require.NotEqual(t, subgraph.NodeIDs, nextSubgraph.NodeIDs, "method=%s", method)
+	}
+
+	// With enough walks every node is sampled in every subgraph: no bias to correct.
+	sampler, err := NewGraphSAINTSampler(adj, SAINTRandomWalks, 10*numNodes).NormalizationSamples(5).Done()
+	require.NoError(t, err)
+	for _, norm := range sampler.NodeNorm().Value().([]float32) {
+		require.InDelta(t, 1.0/numNodes, norm, 1e-6)
+	}
+	for _, norm := range sampler.EdgeNorm().Value().([]float32) {
+		require.Equal(t, float32(1), norm)
+	}
+
+	// A node sampled in half of the subgraphs (node 0 or node 1, never both) has its loss weight doubled.
+	adj, err = NewAdjacency(tensors.FromValue([][]int32{{0, 1}, {1, 0}}), 2)
+	require.NoError(t, err)
+	sampler, err = NewGraphSAINTSampler(adj, SAINTNodes, 1).NormalizationSamples(2000).Done()
+	require.NoError(t, err)
+	nodeNorm := sampler.NodeNorm().Value().([]float32)
+	require.InDelta(t, 1.0, nodeNorm[0], 0.1)
+	require.InDelta(t, 1.0, nodeNorm[1], 0.1)
+	subgraph, err := sampler.Sample()
+	require.NoError(t, err)
+	require.Len(t, subgraph.NodeIDs, 1)
+	require.Nil(t, subgraph.Edges)
+	require.Nil(t, subgraph.EdgeNorm)
+
+	_, err = NewGraphSAINTSampler(adj, SAINTMethod(7), 1).Done()
+	require.Error(t, err)
+	_, err = NewGraphSAINTSampler(adj, SAINTNodes, 0).Done()
+	require.Error(t, err)
+	_, err = NewGraphSAINTSampler(nil, SAINTNodes, 1).Done()
+	require.Error(t, err)
+}
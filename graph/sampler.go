@@ -0,0 +1,333 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// NeighborSamplerConfig is created with NewNeighborSampler and once fully configured, can be executed
+// with Done.
+type NeighborSamplerConfig struct {
+	adj        *Adjacency
+	fanOuts    []int
+	seed       uint64
+	numWorkers int
+	replace    bool
+	outgoing   bool
+}
+
+// NewNeighborSampler creates a GraphSAGE-style neighbor sampler: starting from a set of seed nodes, it samples
+// up to fanOuts[0] neighbors of each seed, then up to fanOuts[1] neighbors of each seed and newly sampled node,
+// and so on.
+//
+// By default, neighbors are sampled from the incoming edges of each node (the edges whose messages the node
+// aggregates). A fan-out of -1 takes all neighbors.
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - adj: the Adjacency of the graph, see NewAdjacency and Graph.Adjacency.
+//   - fanOuts: the maximum number of neighbors sampled per node for each hop, e.g. [25, 10].
+//
+// It returns a configuration that can be optionally configured. Call NeighborSamplerConfig.Done to create
+// the sampler.
+func NewNeighborSampler(adj *Adjacency, fanOuts ...int) *NeighborSamplerConfig {
+	return &NeighborSamplerConfig{
+		adj:        adj,
+		fanOuts:    fanOuts,
+		numWorkers: runtime.NumCPU(),
+	}
+}
+
+// Seed sets the seed of the random number generator. Default is 0.
+func (c *NeighborSamplerConfig) Seed(seed uint64) *NeighborSamplerConfig {
+	c.seed = seed
+	return c
+}
+
+// NumWorkers sets the number of goroutines used to sample each hop. Default is runtime.NumCPU().
+// The sampled subgraphs don't depend on the number of workers.
+func (c *NeighborSamplerConfig) NumWorkers(numWorkers int) *NeighborSamplerConfig {
+	c.numWorkers = numWorkers
+	return c
+}
+
+// WithReplacement samples neighbors with replacement, so each node gets exactly fanOut sampled edges
+// (if it has any neighbor), possibly repeated. The default is to sample without replacement.
+func (c *NeighborSamplerConfig) WithReplacement() *NeighborSamplerConfig {
+	c.replace = true
+	return c
+}
+
+// Outgoing samples neighbors from the outgoing edges of each node, instead of the incoming edges.
+func (c *NeighborSamplerConfig) Outgoing() *NeighborSamplerConfig {
+	c.outgoing = true
+	return c
+}
+
+// Done validates the configuration and returns the NeighborSampler.
+func (c *NeighborSamplerConfig) Done() (*NeighborSampler, error) {
+	if c.adj == nil {
+		return nil, fmt.Errorf("NeighborSampler requires an Adjacency")
+	}
+	if len(c.fanOuts) == 0 {
+		return nil, fmt.Errorf("NeighborSampler requires at least one fan-out")
+	}
+	for hop, fanOut := range c.fanOuts {
+		if fanOut == 0 || fanOut < -1 {
+			return nil, fmt.Errorf("invalid fan-out %d for hop %d: it must be positive, or -1 to take all neighbors", fanOut, hop)
+		}
+	}
+	if c.numWorkers <= 0 {
+		return nil, fmt.Errorf("invalid number of workers %d", c.numWorkers)
+	}
+	return &NeighborSampler{config: *c}, nil
+}
+
+// NeighborSampler samples subgraphs around seed nodes, see NewNeighborSampler.
+// It is safe for concurrent use.
+type NeighborSampler struct {
+	config   NeighborSamplerConfig
+	numCalls atomic.Uint64
+}
+
+// SampledSubgraph is the result of NeighborSampler.Sample.
+type SampledSubgraph struct {
+	// NodeIDs maps the local node indices of the subgraph to the original node indices. The seeds come first, in
+	// the given order, followed by the nodes sampled in each hop in order of discovery.
+	NodeIDs []int32
+
+	// Edges shaped [2, numSampledEdges]Int32 with the distinct edges sampled in any hop, using local node indices.
+	// It is nil if no edges were sampled. Edges keep their original direction (from the sampled neighbor to the
+	// node, unless sampling Outgoing edges).
+	Edges *tensors.Tensor
+
+	// EdgeIDs are the indices of the sampled edges in the original edges tensor, to gather the edge features.
+	EdgeIDs []int32
+
+	// Blocks with the edges sampled in each hop, ordered from the last hop (the input layer of the model)
+	// to the first hop (whose destination nodes are the seeds), as in DGL.
+	Blocks []*Block
+}
+
+// Block is a bipartite graph with the edges sampled in one hop of a NeighborSampler: the messages of one layer
+// of message passing.
+//
+// The destination nodes are the first NumDstNodes of SampledSubgraph.NodeIDs, and the source nodes are the
+// first NumSrcNodes (so destination nodes are also source nodes), so the output of a layer for the
+// source nodes can be sliced to get the input of the next layer.
+type Block struct {
+	NumSrcNodes, NumDstNodes int
+
+	// Edges shaped [2, numEdges]Int32, where Edges[0] are the local indices of the source nodes (neighbors) and
+	// Edges[1] the local indices of the destination nodes. It is nil if no edges were sampled.
+	//
+	// Block edges always point from the neighbor to the destination node, so with NeighborSamplerConfig.Outgoing
+	// they are the reverse of the original edges.
+	Edges *tensors.Tensor
+
+	// EdgeIDs are the indices of the edges in the original edges tensor.
+	EdgeIDs []int32
+}
+
+// sampledNeighbors holds the neighbors sampled for one destination node.
+type sampledNeighbors struct {
+	nodes, edgeIDs []int32
+}
+
+// Sample samples a subgraph around the given seed nodes.
+//
+// At each hop, the neighbors of all the destination nodes of the hop (the seeds and all the nodes sampled in the
+// previous hops) are sampled, so each Block holds the complete input of one layer of message passing.
+//
+// Each call uses a different random stream, derived from the configured seed and the number of previous calls,
+// so a sequence of calls is reproducible.
+func (s *NeighborSampler) Sample(seeds []int32) (*SampledSubgraph, error) {
+	c := &s.config
+	csr := c.adj.In
+	if c.outgoing {
+		csr = c.adj.Out
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no seed nodes given")
+	}
+	callIdx := s.numCalls.Add(1) - 1
+
+	// Local indices of the nodes, assigned in order of discovery.
+	localIdx := make(map[int32]int32, len(seeds))
+	nodeIDs := make([]int32, 0, len(seeds))
+	for _, seed := range seeds {
+		if seed < 0 || int(seed) >= csr.NumNodes {
+			return nil, fmt.Errorf("seed node %d out of range, there are %d nodes", seed, csr.NumNodes)
+		}
+		if _, found := localIdx[seed]; found {
+			return nil, fmt.Errorf("duplicate seed node %d", seed)
+		}
+		localIdx[seed] = int32(len(nodeIDs))
+		nodeIDs = append(nodeIDs, seed)
+	}
+
+	subgraph := &SampledSubgraph{}
+	var allSources, allTargets []int32
+	seenEdges := make(map[int32]bool)
+	blocks := make([]*Block, len(c.fanOuts))
+	for hop, fanOut := range c.fanOuts {
+		numDstNodes := len(nodeIDs)
+		sampled := s.sampleHop(csr, nodeIDs[:numDstNodes], fanOut, callIdx, hop)
+
+		// Relabel sequentially, in destination order, so the result is deterministic.
+		var sources, targets, edgeIDs []int32
+		for dst, neighbors := range sampled {
+			for j, neighbor := range neighbors.nodes {
+				local, found := localIdx[neighbor]
+				if !found {
+					local = int32(len(nodeIDs))
+					localIdx[neighbor] = local
+					nodeIDs = append(nodeIDs, neighbor)
+				}
+				sources, targets = append(sources, local), append(targets, int32(dst))
+				edgeID := neighbors.edgeIDs[j]
+				edgeIDs = append(edgeIDs, edgeID)
+				if !seenEdges[edgeID] {
+					// The subgraph keeps the original direction of the edges.
+					seenEdges[edgeID] = true
+					if c.outgoing {
+						allSources, allTargets = append(allSources, int32(dst)), append(allTargets, local)
+					} else {
+						allSources, allTargets = append(allSources, local), append(allTargets, int32(dst))
+					}
+					subgraph.EdgeIDs = append(subgraph.EdgeIDs, edgeID)
+				}
+			}
+		}
+		blocks[len(c.fanOuts)-1-hop] = &Block{
+			NumSrcNodes: len(nodeIDs),
+			NumDstNodes: numDstNodes,
+			Edges:       edgesFromSlices(sources, targets),
+			EdgeIDs:     edgeIDs,
+		}
+	}
+	subgraph.NodeIDs = nodeIDs
+	subgraph.Edges = edgesFromSlices(allSources, allTargets)
+	subgraph.Blocks = blocks
+	return subgraph, nil
+}
+
+// sampleHop samples the neighbors of each destination node, in parallel.
+// The random stream of each node depends only on the call, hop and position in dstNodes.
+func (s *NeighborSampler) sampleHop(csr *CompressedEdges, dstNodes []int32, fanOut int, callIdx uint64, hop int) []sampledNeighbors {
+	c := &s.config
+	sampled := make([]sampledNeighbors, len(dstNodes))
+	numWorkers := min(c.numWorkers, max(len(dstNodes)/64, 1))
+	var wg sync.WaitGroup
+	for worker := range numWorkers {
+		start, end := worker*len(dstNodes)/numWorkers, (worker+1)*len(dstNodes)/numWorkers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pcg := &rand.PCG{}
+			rng := rand.New(pcg)
+			var chosen []int
+			for i := start; i < end; i++ {
+				pcg.Seed(c.seed, mixStreams(callIdx, uint64(hop), uint64(i)))
+				node := dstNodes[i]
+				rowStart, rowEnd := int(csr.Ptr[node]), int(csr.Ptr[node+1])
+				degree := rowEnd - rowStart
+				if degree == 0 {
+					continue
+				}
+				chosen = chosen[:0]
+				switch {
+				case fanOut == -1 || (!c.replace && degree <= fanOut):
+					for j := range degree {
+						chosen = append(chosen, j)
+					}
+				case c.replace:
+					for range fanOut {
+						chosen = append(chosen, rng.IntN(degree))
+					}
+				default:
+					chosen = sampleWithoutReplacement(rng, degree, fanOut, chosen)
+				}
+				neighbors := sampledNeighbors{
+					nodes:   make([]int32, len(chosen)),
+					edgeIDs: make([]int32, len(chosen)),
+				}
+				for j, position := range chosen {
+					neighbors.nodes[j] = csr.Indices[rowStart+position]
+					neighbors.edgeIDs[j] = csr.Permutation[rowStart+position]
+				}
+				sampled[i] = neighbors
+			}
+		}()
+	}
+	wg.Wait()
+	return sampled
+}
+
+// mixStreams hashes the indices of a random stream into one value, to be used as the second word of a PCG seed
+// (the first being the configured seed), so streams of different samplers don't collide.
+// It chains the splitmix64 finalizer over the values.
+func mixStreams(values ...uint64) uint64 {
+	var h uint64
+	for _, value := range values {
+		h += value + 0x9e3779b97f4a7c15
+		h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+		h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+		h ^= h >> 31
+	}
+	return h
+}
+
+// sampleWithoutReplacement appends to chosen k distinct values in [0, n), using Floyd's algorithm: it takes O(k)
+// time (plus the duplicate checks), independent of n.
+func sampleWithoutReplacement(rng *rand.Rand, n, k int, chosen []int) []int {
+	const maxLinearScan = 32
+	var selected map[int]bool
+	if k > maxLinearScan {
+		selected = make(map[int]bool, k)
+	}
+	contains := func(value int) bool {
+		if selected != nil {
+			return selected[value]
+		}
+		for _, c := range chosen {
+			if c == value {
+				return true
+			}
+		}
+		return false
+	}
+	for j := n - k; j < n; j++ {
+		value := rng.IntN(j + 1)
+		if contains(value) {
+			value = j
+		}
+		chosen = append(chosen, value)
+		if selected != nil {
+			selected[value] = true
+		}
+	}
+	return chosen
+}
+
+// edgesFromSlices creates an edges tensor shaped [2, numEdges]Int32, or returns nil if there are no edges.
+func edgesFromSlices(sources, targets []int32) *tensors.Tensor {
+	numEdges := len(sources)
+	if numEdges == 0 {
+		return nil
+	}
+	edges := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	tensors.MutableFlatData(edges, func(flat []int32) {
+		copy(flat[:numEdges], sources)
+		copy(flat[numEdges:], targets)
+	})
+	return edges
+}
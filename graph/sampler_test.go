@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeighborSampler(t *testing.T) {
+	const numNodes = 500
+	rng := rand.New(rand.NewPCG(42, 0))
+	edges := randomEdges(rng, 10_000, numNodes)
+	adj, err := NewAdjacency(edges, numNodes)
+	require.NoError(t, err)
+	sources, targets := New(numNodes, edges).Sources(), New(numNodes, edges).Targets()
+
+	seeds := []int32{3, 17, 256}
+	fanOuts := []int{5, 3}
+	sample := func(numWorkers int) *SampledSubgraph {
+		sampler, err := NewNeighborSampler(adj, fanOuts...).Seed(7).NumWorkers(numWorkers).Done()
+		require.NoError(t, err)
+		subgraph, err := sampler.Sample(seeds)
+		require.NoError(t, err)
+		return subgraph
+	}
+	subgraph := sample(1)
+	require.Equal(t, seeds, subgraph.NodeIDs[:len(seeds)])
+	require.Len(t, subgraph.Blocks, 2)
+
+	// Blocks: the last one has the seeds as destination nodes.
+	first, last := subgraph.Blocks[1], subgraph.Blocks[0]
+	require.Equal(t, len(seeds), first.NumDstNodes)
+	require.Equal(t, first.NumSrcNodes, last.NumDstNodes)
+	require.Equal(t, len(subgraph.NodeIDs), last.NumSrcNodes)
+	require.LessOrEqual(t, len(subgraph.EdgeIDs), len(first.EdgeIDs)+len(last.EdgeIDs))
+	for hop, block := range []*Block{first, last} {
+		blockEdges := block.Edges.Value().([][]int32)
+		inDegree := make(map[int32]int)
+		for i, edgeID := range block.EdgeIDs {
+			src, dst := blockEdges[0][i], blockEdges[1][i]
+			require.Less(t, int(src), block.NumSrcNodes)
+			require.Less(t, int(dst), block.NumDstNodes)
+			// Sampled edges are incoming edges of the destination node in the original graph.
+			require.Equal(t, sources[edgeID], subgraph.NodeIDs[src])
+			require.Equal(t, targets[edgeID], subgraph.NodeIDs[dst])
+			inDegree[dst]++
+		}
+		// Every destination node (not only the newly sampled ones) gets its neighbors sampled.
+		for dst := range int32(block.NumDstNodes) {
+			count := inDegree[dst]
+			require.LessOrEqual(t, count, fanOuts[hop])
+			require.Equal(t, min(fanOuts[hop], adj.InDegree(int(subgraph.NodeIDs[dst]))), count, "hop %d, dst %d", hop, dst)
+		}
+		// Without replacement, no edge is sampled twice.
+		unique := make(map[int32]bool)
+		for _, edgeID := range block.EdgeIDs {
+			require.False(t, unique[edgeID])
+			unique[edgeID] = true
+		}
+	}
+
+	// Results don't depend on the number of workers, but successive calls differ.
+	parallel := sample(4)
+	require.Equal(t, subgraph.NodeIDs, parallel.NodeIDs)
+	require.Equal(t, subgraph.EdgeIDs, parallel.EdgeIDs)
+	require.Equal(t, subgraph.Edges.Value(), parallel.Edges.Value())
+	sampler, err := NewNeighborSampler(adj, fanOuts...).Seed(7).Done()
+	require.NoError(t, err)
+	subgraph1, err := sampler.Sample(seeds)
+	require.NoError(t, err)
+	subgraph2, err := sampler.Sample(seeds)
+	require.NoError(t, err)
+	require.Equal(t, subgraph.EdgeIDs, subgraph1.EdgeIDs)
+	require.NotEqual(t, subgraph1.EdgeIDs, subgraph2.EdgeIDs)
+
+	// Samplers with different seeds don't share random streams across calls.
+	sampler0, err := NewNeighborSampler(adj, fanOuts...).Seed(0).Done()
+	require.NoError(t, err)
+	sampler1, err := NewNeighborSampler(adj, fanOuts...).Seed(1).Done()
+	require.NoError(t, err)
+	_, err = sampler0.Sample(seeds)
+	require.NoError(t, err)
+	fromSeed0, err := sampler0.Sample(seeds)
+	require.NoError(t, err)
+	fromSeed1, err := sampler1.Sample(seeds)
+	require.NoError(t, err)
+	require.NotEqual(t, fromSeed0.EdgeIDs, fromSeed1.EdgeIDs)
+
+	// With replacement every node with neighbors gets exactly fanOut edges.
+	sampler, err = NewNeighborSampler(adj, 50).WithReplacement().Done()
+	require.NoError(t, err)
+	subgraph, err = sampler.Sample(seeds)
+	require.NoError(t, err)
+	require.Len(t, subgraph.Blocks[0].EdgeIDs, 50*len(seeds))
+
+	// Errors.
+	_, err = NewNeighborSampler(adj).Done()
+	require.Error(t, err)
+	_, err = NewNeighborSampler(adj, 0).Done()
+	require.Error(t, err)
+	_, err = sampler.Sample([]int32{3, 3})
+	require.Error(t, err)
+	_, err = sampler.Sample([]int32{numNodes})
+	require.Error(t, err)
+}
+
+func TestNeighborSamplerSmall(t *testing.T) {
+	// 0 -> 1 -> 2, 3 -> 2; node 0 has no incoming edges.
+	adj, err := NewAdjacency(tensors.FromValue([][]int32{{0, 1, 3}, {1, 2, 2}}), 4)
+	require.NoError(t, err)
+	sampler, err := NewNeighborSampler(adj, -1, -1).Done()
+	require.NoError(t, err)
+	subgraph, err := sampler.Sample([]int32{2})
+	require.NoError(t, err)
+	require.Equal(t, []int32{2, 1, 3, 0}, subgraph.NodeIDs)
+	require.Equal(t, [][]int32{{1, 2, 3}, {0, 0, 1}}, subgraph.Edges.Value())
+	require.Equal(t, []int32{1, 2, 0}, subgraph.EdgeIDs)
+	// The seed aggregates its neighbors in both layers.
+	first, last := subgraph.Blocks[1], subgraph.Blocks[0]
+	require.Equal(t, 1, first.NumDstNodes)
+	require.Equal(t, 3, first.NumSrcNodes)
+	require.Equal(t, [][]int32{{1, 2}, {0, 0}}, first.Edges.Value())
+	require.Equal(t, 3, last.NumDstNodes)
+	require.Equal(t, 4, last.NumSrcNodes)
+	require.Equal(t, [][]int32{{1, 2, 3}, {0, 0, 1}}, last.Edges.Value())
+	require.Equal(t, []int32{1, 2, 0}, last.EdgeIDs)
+
+	subgraph, err = sampler.Sample([]int32{0})
+	require.NoError(t, err)
+	require.Nil(t, subgraph.Edges)
+	require.Nil(t, subgraph.Blocks[0].Edges)
+
+	// Sampling outgoing edges.
+	sampler, err = NewNeighborSampler(adj, -1).Outgoing().Done()
+	require.NoError(t, err)
+	subgraph, err = sampler.Sample([]int32{0})
+	require.NoError(t, err)
+	require.Equal(t, []int32{0, 1}, subgraph.NodeIDs)
+	require.Equal(t, [][]int32{{0}, {1}}, subgraph.Edges.Value())
+	// Block edges point from the sampled neighbor to the destination node.
+	require.Equal(t, 1, subgraph.Blocks[0].NumDstNodes)
+	require.Equal(t, [][]int32{{1}, {0}}, subgraph.Blocks[0].Edges.Value())
+}
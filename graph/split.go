@@ -0,0 +1,318 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// NodeSplitConfig is created with RandomNodeSplit and once fully configured, can be executed
+// with Done.
+type NodeSplitConfig struct {
+	numNodes         int
+	numVal, numTest  int
+	valFraction      float64
+	testFraction     float64
+	useFractions     bool
+	labels           []int32
+	numTrainPerClass int
+	seed             uint64
+}
+
+// NodeSplit holds the result of RandomNodeSplit: disjoint masks shaped [numNodes]Bool.
+type NodeSplit struct {
+	TrainMask, ValMask, TestMask *tensors.Tensor
+}
+
+// RandomNodeSplit randomly splits the nodes into train, validation and test sets, for node classification.
+//
+// By default, 10% of the nodes are used for validation, 10% for test and the rest for training. With
+// NodeSplitConfig.TrainPerClass, a fixed number of training nodes per class is used instead (as in the Planetoid
+// datasets), and the nodes not selected for any set are left out.
+//
+// It returns a configuration that can be optionally configured. Call NodeSplitConfig.Done to perform
+// the operation.
+func RandomNodeSplit(numNodes int) *NodeSplitConfig {
+	return &NodeSplitConfig{
+		numNodes:     numNodes,
+		valFraction:  0.1,
+		testFraction: 0.1,
+		useFractions: true,
+	}
+}
+
+// Counts sets the number of validation and test nodes.
+func (c *NodeSplitConfig) Counts(numVal, numTest int) *NodeSplitConfig {
+	c.numVal, c.numTest = numVal, numTest
+	c.useFractions = false
+	return c
+}
+
+// Fractions sets the fraction of the nodes used for validation and test. Default is 0.1 for both.
+func (c *NodeSplitConfig) Fractions(valFraction, testFraction float64) *NodeSplitConfig {
+	c.valFraction, c.testFraction = valFraction, testFraction
+	c.useFractions = true
+	return c
+}
+
+// TrainPerClass selects numTrainPerClass random training nodes for each class, given the labels of the nodes
+// (with len(labels) == numNodes). Nodes with negative labels are never used for training.
+// The validation and test nodes are then drawn from the remaining nodes.
+func (c *NodeSplitConfig) TrainPerClass(labels []int32, numTrainPerClass int) *NodeSplitConfig {
+	c.labels, c.numTrainPerClass = labels, numTrainPerClass
+	return c
+}
+
+// Seed sets the seed of the random number generator. Default is 0.
+func (c *NodeSplitConfig) Seed(seed uint64) *NodeSplitConfig {
+	c.seed = seed
+	return c
+}
+
+// Done performs the split as configured.
+func (c *NodeSplitConfig) Done() (*NodeSplit, error) {
+	if c.numNodes <= 0 {
+		return nil, fmt.Errorf("invalid number of nodes %d", c.numNodes)
+	}
+	numVal, numTest, err := splitCounts(c.numNodes, c.useFractions, c.valFraction, c.testFraction, c.numVal, c.numTest)
+	if err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewPCG(c.seed, 0))
+	permutation := rng.Perm(c.numNodes)
+	train := make([]bool, c.numNodes)
+	val := make([]bool, c.numNodes)
+	test := make([]bool, c.numNodes)
+
+	var rest []int
+	if c.labels == nil {
+		if numVal+numTest >= c.numNodes {
+			return nil, fmt.Errorf("no nodes left for training: %d nodes, %d for validation and %d for test",
+				c.numNodes, numVal, numTest)
+		}
+		rest = permutation
+	} else {
+		if len(c.labels) != c.numNodes {
+			return nil, fmt.Errorf("got %d labels for %d nodes", len(c.labels), c.numNodes)
+		}
+		if c.numTrainPerClass <= 0 {
+			return nil, fmt.Errorf("invalid number of training nodes per class %d", c.numTrainPerClass)
+		}
+		perClass := make(map[int32]int)
+		for _, nodeIdx := range permutation {
+			label := c.labels[nodeIdx]
+			if label >= 0 && perClass[label] < c.numTrainPerClass {
+				perClass[label]++
+				train[nodeIdx] = true
+				continue
+			}
+			rest = append(rest, nodeIdx)
+		}
+		if numVal+numTest > len(rest) {
+			return nil, fmt.Errorf("not enough nodes for %d validation and %d test nodes: only %d left after selecting "+
+				"the training nodes", numVal, numTest, len(rest))
+		}
+	}
+	for i, nodeIdx := range rest {
+		switch {
+		case i < numVal:
+			val[nodeIdx] = true
+		case i < numVal+numTest:
+			test[nodeIdx] = true
+		case c.labels == nil:
+			train[nodeIdx] = true
+		}
+	}
+	return &NodeSplit{
+		TrainMask: tensors.FromValue(train),
+		ValMask:   tensors.FromValue(val),
+		TestMask:  tensors.FromValue(test),
+	}, nil
+}
+
+// splitCounts returns the number of validation and test items, either from the fractions or the given counts.
+func splitCounts(numItems int, useFractions bool, valFraction, testFraction float64, numVal, numTest int) (int, int, error) {
+	if useFractions {
+		if valFraction < 0 || testFraction < 0 || valFraction+testFraction >= 1 {
+			return 0, 0, fmt.Errorf("invalid validation (%g) and test (%g) fractions: they must be >= 0 and add up to less than 1",
+				valFraction, testFraction)
+		}
+		numVal = int(math.Round(valFraction * float64(numItems)))
+		numTest = int(math.Round(testFraction * float64(numItems)))
+	}
+	if numVal < 0 || numTest < 0 {
+		return 0, 0, fmt.Errorf("invalid number of validation (%d) or test (%d) items", numVal, numTest)
+	}
+	return numVal, numTest, nil
+}
+
+// LinkSplitConfig is created with RandomLinkSplit and once fully configured, can be executed
+// with Done.
+type LinkSplitConfig struct {
+	edges                     *tensors.Tensor
+	numNodes                  int
+	valFraction, testFraction float64
+	undirected                bool
+	negativeRatio             float64
+	seed                      uint64
+}
+
+// LinkSplit holds the result of RandomLinkSplit.
+type LinkSplit struct {
+	// TrainMask, ValMask and TestMask are disjoint masks shaped [numEdges]Bool over the input edges.
+	// Use them to slice the edge features.
+	TrainMask, ValMask, TestMask *tensors.Tensor
+
+	// TrainEdges, ValEdges and TestEdges shaped [2, numSplitEdges]Int32 are the edges of each split, in their
+	// original order. They are nil if the split is empty.
+	//
+	// TrainEdges should be used for message passing during training and validation, and the union of TrainEdges
+	// and ValEdges (see UnionEdges) for message passing during test.
+	TrainEdges, ValEdges, TestEdges *tensors.Tensor
+
+	// ValNegatives and TestNegatives shaped [2, numNegatives]Int32 are negative edges (not in the input edges)
+	// for the validation and test sets, if LinkSplitConfig.Negatives was configured.
+	ValNegatives, TestNegatives *tensors.Tensor
+}
+
+// RandomLinkSplit randomly splits the edges into train, validation and test sets, for link prediction.
+//
+// By default, 10% of the edges are used for validation, 10% for test and the rest for training. For undirected
+// graphs (see LinkSplitConfig.Undirected) both directions of an edge are kept in the same set, so no information
+// leaks from the training graph.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - numNodes: number of nodes of the graph.
+//
+// It returns a configuration that can be optionally configured. Call LinkSplitConfig.Done to perform
+// the operation.
+func RandomLinkSplit(edges *tensors.Tensor, numNodes int) *LinkSplitConfig {
+	return &LinkSplitConfig{
+		edges:        edges,
+		numNodes:     numNodes,
+		valFraction:  0.1,
+		testFraction: 0.1,
+	}
+}
+
+// Fractions sets the fraction of the edges used for validation and test. Default is 0.1 for both.
+// For undirected graphs, the fractions are of the undirected pairs.
+func (c *LinkSplitConfig) Fractions(valFraction, testFraction float64) *LinkSplitConfig {
+	c.valFraction, c.testFraction = valFraction, testFraction
+	return c
+}
+
+// Undirected keeps the edges i->j and j->i together in the same split.
+func (c *LinkSplitConfig) Undirected() *LinkSplitConfig {
+	c.undirected = true
+	return c
+}
+
+// Negatives generates ratio negative edges per positive edge for the validation and test sets, with uniform
+// NegativeSampling. Default is 0, no negatives.
+func (c *LinkSplitConfig) Negatives(ratio float64) *LinkSplitConfig {
+	c.negativeRatio = ratio
+	return c
+}
+
+// Seed sets the seed of the random number generator. Default is 0.
+func (c *LinkSplitConfig) Seed(seed uint64) *LinkSplitConfig {
+	c.seed = seed
+	return c
+}
+
+// Done performs the split as configured.
+func (c *LinkSplitConfig) Done() (*LinkSplit, error) {
+	if err := checkEdges(c.edges); err != nil {
+		return nil, err
+	}
+	if c.negativeRatio < 0 {
+		return nil, fmt.Errorf("invalid negative ratio %g", c.negativeRatio)
+	}
+	numEdges := c.edges.Shape().Dimensions[1]
+
+	// Group the edges: each group is a directed edge, or an undirected pair.
+	groupOf := make([]int, numEdges)
+	var numGroups int
+	groups := make(map[uint64]int)
+	tensors.ConstFlatData(c.edges, func(flat []int32) {
+		for edgeIdx := range numEdges {
+			source, target := flat[edgeIdx], flat[numEdges+edgeIdx]
+			if c.undirected && target < source {
+				source, target = target, source
+			}
+			key := edgeKey(source, target)
+			group, found := groups[key]
+			if !found {
+				group = numGroups
+				groups[key] = group
+				numGroups++
+			}
+			groupOf[edgeIdx] = group
+		}
+	})
+	numVal, numTest, err := splitCounts(numGroups, true, c.valFraction, c.testFraction, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if numVal+numTest >= numGroups {
+		return nil, fmt.Errorf("no edges left for training: %d edges, %d for validation and %d for test",
+			numGroups, numVal, numTest)
+	}
+
+	// Assign the groups in random order: 0 for train, 1 for validation and 2 for test.
+	rng := rand.New(rand.NewPCG(c.seed, 0))
+	groupSplit := make([]int, numGroups)
+	for i, group := range rng.Perm(numGroups) {
+		switch {
+		case i < numVal:
+			groupSplit[group] = 1
+		case i < numVal+numTest:
+			groupSplit[group] = 2
+		}
+	}
+	masks := [3][]bool{make([]bool, numEdges), make([]bool, numEdges), make([]bool, numEdges)}
+	var indices [3][]int32
+	for edgeIdx, group := range groupOf {
+		split := groupSplit[group]
+		masks[split][edgeIdx] = true
+		indices[split] = append(indices[split], int32(edgeIdx))
+	}
+	var splitEdges [3]*tensors.Tensor
+	for split := range splitEdges {
+		if len(indices[split]) > 0 {
+			splitEdges[split] = selectEdges(c.edges, indices[split])
+		}
+	}
+	result := &LinkSplit{
+		TrainMask:  tensors.FromValue(masks[0]),
+		ValMask:    tensors.FromValue(masks[1]),
+		TestMask:   tensors.FromValue(masks[2]),
+		TrainEdges: splitEdges[0],
+		ValEdges:   splitEdges[1],
+		TestEdges:  splitEdges[2],
+	}
+	if c.negativeRatio > 0 {
+		for _, target := range []struct {
+			split int
+			dst   **tensors.Tensor
+		}{
+			{split: 1, dst: &result.ValNegatives},
+			{split: 2, dst: &result.TestNegatives},
+		} {
+			numNegatives := int(math.Round(c.negativeRatio * float64(len(indices[target.split]))))
+			if numNegatives == 0 {
+				continue
+			}
+			*target.dst, err = NegativeSampling(c.edges, c.numNodes, numNegatives, UniformNegatives).
+				Seed(c.seed + uint64(target.split)).Done()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
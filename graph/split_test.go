@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func countTrue(mask *tensors.Tensor) int {
+	var count int
+	for _, value := range mask.Value().([]bool) {
+		if value {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRandomNodeSplit(t *testing.T) {
+	split, err := RandomNodeSplit(100).Fractions(0.2, 0.3).Seed(1).Done()
+	require.NoError(t, err)
+	require.Equal(t, 50, countTrue(split.TrainMask))
+	require.Equal(t, 20, countTrue(split.ValMask))
+	require.Equal(t, 30, countTrue(split.TestMask))
+	train, val, test := split.TrainMask.Value().([]bool), split.ValMask.Value().([]bool), split.TestMask.Value().([]bool)
+	for i := range train {
+		require.Equal(t, 1, boolToInt(train[i])+boolToInt(val[i])+boolToInt(test[i]), "node %d", i)
+	}
+
+	// Deterministic by seed.
+	again, err := RandomNodeSplit(100).Fractions(0.2, 0.3).Seed(1).Done()
+	require.NoError(t, err)
+	require.Equal(t, split.TestMask.Value(), again.TestMask.Value())
+
+	// Planetoid style: 2 training nodes per class, the rest are left out.
+	labels := make([]int32, 30)
+	for i := range labels {
+		labels[i] = int32(i % 3)
+	}
+	labels[0] = -1
+	split, err = RandomNodeSplit(30).TrainPerClass(labels, 2).Counts(5, 10).Done()
+	require.NoError(t, err)
+	require.Equal(t, 6, countTrue(split.TrainMask))
+	require.Equal(t, 5, countTrue(split.ValMask))
+	require.Equal(t, 10, countTrue(split.TestMask))
+	perClass := make(map[int32]int)
+	for i, isTrain := range split.TrainMask.Value().([]bool) {
+		if isTrain {
+			perClass[labels[i]]++
+		}
+	}
+	require.Equal(t, map[int32]int{0: 2, 1: 2, 2: 2}, perClass)
+
+	_, err = RandomNodeSplit(10).Fractions(0.5, 0.5).Done()
+	require.Error(t, err)
+	_, err = RandomNodeSplit(10).Counts(5, 5).Done()
+	require.Error(t, err)
+	_, err = RandomNodeSplit(30).TrainPerClass(labels, 2).Counts(20, 10).Done()
+	require.Error(t, err)
+	_, err = RandomNodeSplit(30).TrainPerClass(labels[:3], 2).Done()
+	require.Error(t, err)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestRandomLinkSplit(t *testing.T) {
+	edges := cycleEdges(50)
+	split, err := RandomLinkSplit(edges, 50).Undirected().Fractions(0.2, 0.2).Negatives(1).Seed(3).Done()
+	require.NoError(t, err)
+	require.Equal(t, 60, countTrue(split.TrainMask))
+	require.Equal(t, 20, countTrue(split.ValMask))
+	require.Equal(t, 20, countTrue(split.TestMask))
+	require.Equal(t, []int{2, 60}, split.TrainEdges.Shape().Dimensions)
+
+	// Both directions of each edge are in the same split.
+	splitOf := make(map[[2]int32]int)
+	for splitIdx, splitEdges := range []*tensors.Tensor{split.TrainEdges, split.ValEdges, split.TestEdges} {
+		e := splitEdges.Value().([][]int32)
+		for i := range e[0] {
+			splitOf[[2]int32{e[0][i], e[1][i]}] = splitIdx
+		}
+	}
+	for edge, splitIdx := range splitOf {
+		require.Equal(t, splitIdx, splitOf[[2]int32{edge[1], edge[0]}])
+	}
+
+	// Negatives are not edges of the graph.
+	require.Equal(t, []int{2, 20}, split.ValNegatives.Shape().Dimensions)
+	require.Equal(t, []int{2, 20}, split.TestNegatives.Shape().Dimensions)
+	for _, negatives := range []*tensors.Tensor{split.ValNegatives, split.TestNegatives} {
+		e := negatives.Value().([][]int32)
+		for i := range e[0] {
+			_, found := splitOf[[2]int32{e[0][i], e[1][i]}]
+			require.False(t, found)
+		}
+	}
+
+	// Directed split, without negatives.
+	split, err = RandomLinkSplit(edges, 50).Fractions(0.1, 0).Done()
+	require.NoError(t, err)
+	require.Equal(t, 10, countTrue(split.ValMask))
+	require.Nil(t, split.TestEdges)
+	require.Nil(t, split.ValNegatives)
+
+	_, err = RandomLinkSplit(edges, 50).Fractions(0.6, 0.6).Done()
+	require.Error(t, err)
+}
@@ -0,0 +1,270 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// StructuralFeature is a node feature computed from the structure of the graph, see StructuralFeatures.
+type StructuralFeature int
+
+const (
+	// FeatureInDegree is the number of incoming edges of the node.
+	FeatureInDegree StructuralFeature = iota
+
+	// FeatureOutDegree is the number of outgoing edges of the node.
+	FeatureOutDegree
+
+	// FeatureLogDegree is log(1 + in-degree), as used by PNA's degree scalers.
+	FeatureLogDegree
+
+	// FeatureTriangles is the number of triangles the node is part of, in the undirected simple graph.
+	FeatureTriangles
+
+	// FeatureClustering is the local clustering coefficient of the node in the undirected simple graph:
+	// the fraction of pairs of its neighbors that are connected. It is 0 for nodes with fewer than 2 neighbors.
+	FeatureClustering
+
+	// FeatureCoreNumber is the k-core number of the node in the undirected simple graph: the largest k such that
+	// the node belongs to a subgraph where all nodes have degree >= k.
+	FeatureCoreNumber
+)
+
+// String implements fmt.Stringer.
+func (f StructuralFeature) String() string {
+	switch f {
+	case FeatureInDegree:
+		return "FeatureInDegree"
+	case FeatureOutDegree:
+		return "FeatureOutDegree"
+	case FeatureLogDegree:
+		return "FeatureLogDegree"
+	case FeatureTriangles:
+		return "FeatureTriangles"
+	case FeatureClustering:
+		return "FeatureClustering"
+	case FeatureCoreNumber:
+		return "FeatureCoreNumber"
+	default:
+		return fmt.Sprintf("StructuralFeature(%d)", int(f))
+	}
+}
+
+// StructuralFeaturesConfig is created with StructuralFeatures and once fully configured, can be executed
+// with Done.
+type StructuralFeaturesConfig struct {
+	edges      *tensors.Tensor
+	numNodes   int
+	features   []StructuralFeature
+	numWorkers int
+}
+
+// StructuralFeatures computes structural node features, typically used as input features of GIN and PNA
+// baselines for graphs without node features.
+//
+// The degrees count all the edges (including duplicates and self-loops), while the triangles, clustering
+// coefficient and core number are computed on the undirected simple graph: ignoring the direction of the edges,
+// self-loops and duplicate edges, as in NetworkX. All features are exact.
+//
+// This runs only on CPU -- no graphs or backends are used.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - numNodes: number of nodes of the graph.
+//   - features: the features to compute, in the order of the output columns. If none is given, all the features
+//     are computed, in the order of their definition.
+//
+// It returns a configuration that can be optionally configured. Call StructuralFeaturesConfig.Done to perform
+// the operation.
+func StructuralFeatures(edges *tensors.Tensor, numNodes int, features ...StructuralFeature) *StructuralFeaturesConfig {
+	if len(features) == 0 {
+		for feature := FeatureInDegree; feature <= FeatureCoreNumber; feature++ {
+			features = append(features, feature)
+		}
+	}
+	return &StructuralFeaturesConfig{
+		edges:      edges,
+		numNodes:   numNodes,
+		features:   features,
+		numWorkers: runtime.NumCPU(),
+	}
+}
+
+// NumWorkers sets the number of goroutines used to count the triangles. Default is runtime.NumCPU(), and 1 runs
+// sequentially. The features don't depend on the number of workers.
+func (c *StructuralFeaturesConfig) NumWorkers(numWorkers int) *StructuralFeaturesConfig {
+	c.numWorkers = numWorkers
+	return c
+}
+
+// Done computes the features as configured.
+//
+// It returns a tensor shaped [numNodes, numFeatures]Float32.
+func (c *StructuralFeaturesConfig) Done() (*tensors.Tensor, error) {
+	if c.numWorkers <= 0 {
+		return nil, fmt.Errorf("invalid number of workers %d", c.numWorkers)
+	}
+	var needsDegrees, needsSimple bool
+	for _, feature := range c.features {
+		switch feature {
+		case FeatureInDegree, FeatureOutDegree, FeatureLogDegree:
+			needsDegrees = true
+		case FeatureTriangles, FeatureClustering, FeatureCoreNumber:
+			needsSimple = true
+		default:
+			return nil, fmt.Errorf("invalid structural feature %s", feature)
+		}
+	}
+	numNodes := c.numNodes
+	var inDegrees, outDegrees []int32
+	if needsDegrees {
+		adj, err := NewAdjacency(c.edges, numNodes)
+		if err != nil {
+			return nil, err
+		}
+		inDegrees, outDegrees = adj.InDegrees(), adj.OutDegrees()
+	}
+	var simple *weightedGraph
+	var triangles, coreNumbers []int32
+	if needsSimple {
+		var err error
+		simple, err = newWeightedGraph(c.edges, numNodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	numFeatures := len(c.features)
+	result := tensors.FromShape(shapes.Make(dtypes.Float32, numNodes, numFeatures))
+	tensors.MutableFlatData(result, func(flat []float32) {
+		for column, feature := range c.features {
+			var value func(node int) float64
+			switch feature {
+			case FeatureInDegree:
+				value = func(node int) float64 { return float64(inDegrees[node]) }
+			case FeatureOutDegree:
+				value = func(node int) float64 { return float64(outDegrees[node]) }
+			case FeatureLogDegree:
+				value = func(node int) float64 { return math.Log1p(float64(inDegrees[node])) }
+			case FeatureTriangles:
+				if triangles == nil {
+					triangles = simple.triangles(c.numWorkers)
+				}
+				value = func(node int) float64 { return float64(triangles[node]) }
+			case FeatureClustering:
+				if triangles == nil {
+					triangles = simple.triangles(c.numWorkers)
+				}
+				value = func(node int) float64 {
+					degree := float64(simple.ptr[node+1] - simple.ptr[node])
+					if degree < 2 {
+						return 0
+					}
+					return 2 * float64(triangles[node]) / (degree * (degree - 1))
+				}
+			case FeatureCoreNumber:
+				if coreNumbers == nil {
+					coreNumbers = simple.coreNumbers()
+				}
+				value = func(node int) float64 { return float64(coreNumbers[node]) }
+			}
+			for node := range numNodes {
+				flat[node*numFeatures+column] = float32(value(node))
+			}
+		}
+	})
+	return result, nil
+}
+
+// triangles counts the triangles of each node, in parallel: for each node u, it marks its neighbors, and counts the
+// neighbors w > v of each neighbor v that are marked, so each triangle of u is counted once.
+func (wg *weightedGraph) triangles(numWorkers int) []int32 {
+	numNodes := wg.numNodes()
+	triangles := make([]int32, numNodes)
+	numWorkers = min(numWorkers, max(numNodes/256, 1))
+	var group sync.WaitGroup
+	for worker := range numWorkers {
+		start, end := worker*numNodes/numWorkers, (worker+1)*numNodes/numWorkers
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			marked := make([]bool, numNodes)
+			for node := start; node < end; node++ {
+				neighbors := wg.adj[wg.ptr[node]:wg.ptr[node+1]]
+				for _, neighbor := range neighbors {
+					marked[neighbor] = true
+				}
+				var count int32
+				for _, neighbor := range neighbors {
+					// Count each pair {neighbor, other} of connected neighbors once.
+					for _, other := range wg.adj[wg.ptr[neighbor]:wg.ptr[neighbor+1]] {
+						if other > neighbor && marked[other] {
+							count++
+						}
+					}
+				}
+				for _, neighbor := range neighbors {
+					marked[neighbor] = false
+				}
+				triangles[node] = count
+			}
+		}()
+	}
+	group.Wait()
+	return triangles
+}
+
+// coreNumbers computes the k-core number of each node with the O(numNodes + numEdges) bucket algorithm of
+// Batagelj and Zaversnik (2003): nodes are removed in increasing order of their current degree.
+func (wg *weightedGraph) coreNumbers() []int32 {
+	numNodes := wg.numNodes()
+	degrees := make([]int32, numNodes)
+	var maxDegree int32
+	for node := range numNodes {
+		degrees[node] = wg.ptr[node+1] - wg.ptr[node]
+		maxDegree = max(maxDegree, degrees[node])
+	}
+
+	// Nodes sorted by degree (counting sort), with bucketStart[d] the position of the first node of degree d.
+	bucketStart := make([]int32, maxDegree+2)
+	for _, degree := range degrees {
+		bucketStart[degree+1]++
+	}
+	for degree := int32(1); degree <= maxDegree+1; degree++ {
+		bucketStart[degree] += bucketStart[degree-1]
+	}
+	order := make([]int32, numNodes)
+	position := make([]int32, numNodes)
+	next := append([]int32(nil), bucketStart...)
+	for node, degree := range degrees {
+		position[node] = next[degree]
+		order[position[node]] = int32(node)
+		next[degree]++
+	}
+
+	for i := range numNodes {
+		node := order[i]
+		for _, neighbor := range wg.adj[wg.ptr[node]:wg.ptr[node+1]] {
+			if degrees[neighbor] <= degrees[node] {
+				continue
+			}
+			// Move neighbor to the start of its bucket, and shrink the bucket: its degree decreases by one.
+			degree := degrees[neighbor]
+			first := bucketStart[degree]
+			firstNode := order[first]
+			if firstNode != neighbor {
+				order[first], order[position[neighbor]] = neighbor, firstNode
+				position[firstNode], position[neighbor] = position[neighbor], first
+			}
+			bucketStart[degree]++
+			degrees[neighbor]--
+		}
+	}
+	return degrees
+}
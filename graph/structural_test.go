@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuralFeatures(t *testing.T) {
+	// Triangle 0-1-2 (with a duplicate and a reversed edge), 2 -> 3, self-loop 3 -> 3, and 4 is isolated.
+	edges := tensors.FromValue([][]int32{{0, 1, 2, 1, 2, 3}, {1, 2, 0, 0, 3, 3}})
+	features, err := StructuralFeatures(edges, 5).Done()
+	require.NoError(t, err)
+	log := func(x float64) float32 { return float32(math.Log1p(x)) }
+	require.Equal(t, [][]float32{
+		// In, out, log-degree, triangles, clustering, core number.
+		{2, 1, log(2), 1, 1, 2},
+		{1, 2, log(1), 1, 1, 2},
+		{1, 2, log(1), 1, 1.0 / 3.0, 2},
+		{2, 1, log(2), 0, 0, 1},
+		{0, 0, 0, 0, 0, 0},
+	}, features.Value())
+
+	features, err = StructuralFeatures(edges, 5, FeatureCoreNumber, FeatureInDegree).Done()
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{2, 2}, {2, 1}, {2, 1}, {1, 2}, {0, 0}}, features.Value())
+
+	_, err = StructuralFeatures(edges, 5, StructuralFeature(10)).Done()
+	require.Error(t, err)
+	_, err = StructuralFeatures(edges, 3).Done()
+	require.Error(t, err)
+
+	// Compare with brute force on a random graph, for different numbers of workers.
+	const numNodes = 600
+	rng := rand.New(rand.NewPCG(42, 0))
+	edges = randomEdges(rng, 6_000, numNodes)
+	connected := make([][]bool, numNodes)
+	for i := range connected {
+		connected[i] = make([]bool, numNodes)
+	}
+	g := New(numNodes, edges)
+	for i, source := range g.Sources() {
+		if target := g.Targets()[i]; source != target {
+			connected[source][target], connected[target][source] = true, true
+		}
+	}
+	wantTriangles := make([]float32, numNodes)
+	for a := range numNodes {
+		for b := a + 1; b < numNodes; b++ {
+			if !connected[a][b] {
+				continue
+			}
+			for c := b + 1; c < numNodes; c++ {
+				if connected[a][c] && connected[b][c] {
+					wantTriangles[a]++
+					wantTriangles[b]++
+					wantTriangles[c]++
+				}
+			}
+		}
+	}
+	wantCores := bruteForceCores(connected)
+	for _, numWorkers := range []int{1, 4} {
+		features, err = StructuralFeatures(edges, numNodes, FeatureTriangles, FeatureCoreNumber).
+			NumWorkers(numWorkers).Done()
+		require.NoError(t, err)
+		for node, row := range features.Value().([][]float32) {
+			require.Equal(t, wantTriangles[node], row[0], "triangles of node %d", node)
+			require.Equal(t, wantCores[node], row[1], "core number of node %d", node)
+		}
+	}
+}
+
+// bruteForceCores computes the core numbers by repeatedly removing the nodes with degree below k, for increasing k.
+func bruteForceCores(connected [][]bool) []float32 {
+	numNodes := len(connected)
+	cores := make([]float32, numNodes)
+	removed := make([]bool, numNodes)
+	for k := 1; ; k++ {
+		for changed := true; changed; {
+			changed = false
+			for node := range numNodes {
+				if removed[node] {
+					continue
+				}
+				var degree int
+				for other := range numNodes {
+					if connected[node][other] && !removed[other] {
+						degree++
+					}
+				}
+				if degree < k {
+					removed[node] = true
+					changed = true
+				}
+			}
+		}
+		remaining := false
+		for node := range numNodes {
+			if !removed[node] {
+				cores[node] = float32(k)
+				remaining = true
+			}
+		}
+		if !remaining {
+			return cores
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/gomlx/gomlx/types/shapes"
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/gomlx/gopjrt/dtypes"
+)
+
+// concatenateEdges concatenates the valid edges tensors along the edges axis.
+func concatenateEdges(edgesList ...*tensors.Tensor) *tensors.Tensor {
+	var numEdges int
+	for _, edges := range edgesList {
+		numEdges += edges.Shape().Dimensions[1]
+	}
+	output := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numEdges))
+	tensors.MutableFlatData(output, func(outputFlat []int32) {
+		var pos int
+		for _, edges := range edgesList {
+			n := edges.Shape().Dimensions[1]
+			tensors.ConstFlatData(edges, func(flat []int32) {
+				copy(outputFlat[pos:pos+n], flat[:n])
+				copy(outputFlat[numEdges+pos:numEdges+pos+n], flat[n:])
+			})
+			pos += n
+		}
+	})
+	return output
+}
+
+// selectEdges returns the valid edges selected by indices.
+func selectEdges(edges *tensors.Tensor, indices []int32) *tensors.Tensor {
+	numEdges := edges.Shape().Dimensions[1]
+	output := tensors.FromShape(shapes.Make(dtypes.Int32, 2, len(indices)))
+	tensors.MutableFlatData(output, func(outputFlat []int32) {
+		tensors.ConstFlatData(edges, func(flat []int32) {
+			for i, edgeIdx := range indices {
+				outputFlat[i] = flat[edgeIdx]
+				outputFlat[len(indices)+i] = flat[numEdges+int(edgeIdx)]
+			}
+		})
+	})
+	return output
+}
+
+// ReverseEdges returns a new edges tensor with the direction of all edges reversed (source and target swapped).
+func ReverseEdges(edges *tensors.Tensor) (*tensors.Tensor, error) {
+	if err := checkEdges(edges); err != nil {
+		return nil, err
+	}
+	numEdges := edges.Shape().Dimensions[1]
+	output := tensors.FromShape(edges.Shape())
+	tensors.MutableFlatData(output, func(outputFlat []int32) {
+		tensors.ConstFlatData(edges, func(flat []int32) {
+			copy(outputFlat[:numEdges], flat[numEdges:])
+			copy(outputFlat[numEdges:], flat[:numEdges])
+		})
+	})
+	return output, nil
+}
+
+// ReverseEdges reverses the direction of all edges of the graph. Edge features are kept as is.
+func (g *Graph) ReverseEdges() error {
+	reversed, err := ReverseEdges(g.Edges)
+	if err != nil {
+		return err
+	}
+	g.Edges = reversed
+	return nil
+}
+
+// ToUndirected returns the edges with their reverse added, so that for every edge i->j there is also an edge j->i.
+// Duplicate edges are removed, and the output is sorted by source and then by target (see UnionEdges).
+func ToUndirected(edges *tensors.Tensor) (*tensors.Tensor, error) {
+	reversed, err := ReverseEdges(edges)
+	if err != nil {
+		return nil, err
+	}
+	return UnionEdges(edges, reversed)
+}
+
+// ToUndirected adds the reverse of each edge of the graph, and removes duplicates, sorting the edges by source and
+// then by target.
+//
+// If the graph has EdgeFeatures, the reversed edges get the same features as the original edges, and the features
+// of duplicate edges (including an edge i->j and the reverse of an edge j->i) are combined with reduce
+// (see CoalesceEdges). Notice that with ReduceSum, as in PyG, edges already present in both directions get their
+// features summed.
+func (g *Graph) ToUndirected(reduce Reduction) error {
+	if g.EdgeFeatures == nil {
+		undirected, err := ToUndirected(g.Edges)
+		if err != nil {
+			return err
+		}
+		g.Edges = undirected
+		return nil
+	}
+	if err := checkLeadingDim("EdgeFeatures", g.EdgeFeatures, g.NumEdges()); err != nil {
+		return err
+	}
+	reversed, err := ReverseEdges(g.Edges)
+	if err != nil {
+		return err
+	}
+	features, err := ConcatenateRows(g.EdgeFeatures, g.EdgeFeatures)
+	if err != nil {
+		return err
+	}
+	edges, features, err := CoalesceEdges(concatenateEdges(g.Edges, reversed), features, reduce)
+	if err != nil {
+		return err
+	}
+	g.Edges, g.EdgeFeatures = edges, features
+	return nil
+}
+
+// AddSelfLoops returns the edges with a self-loop i->i appended for every node in [0, numNodes).
+//
+// As in PyG's add_self_loops, the loops are added even if the node already has one. Use RemoveSelfLoops first
+// to avoid duplicate loops.
+func AddSelfLoops(edges *tensors.Tensor, numNodes int) (*tensors.Tensor, error) {
+	if err := checkEdges(edges); err != nil {
+		return nil, err
+	}
+	if numNodes <= 0 {
+		return nil, fmt.Errorf("invalid number of nodes %d", numNodes)
+	}
+	loops := tensors.FromShape(shapes.Make(dtypes.Int32, 2, numNodes))
+	tensors.MutableFlatData(loops, func(flat []int32) {
+		for nodeIdx := range numNodes {
+			flat[nodeIdx] = int32(nodeIdx)
+			flat[numNodes+nodeIdx] = int32(nodeIdx)
+		}
+	})
+	return concatenateEdges(edges, loops), nil
+}
+
+// AddSelfLoops appends a self-loop for every node of the graph. See the function AddSelfLoops.
+//
+// If the graph has EdgeFeatures, loopFeatures shaped [NumNodes, ...] (with the same dtype and inner dimensions as
+// EdgeFeatures) are used as the features of the new loops. If loopFeatures is nil, the loops get zero features.
+func (g *Graph) AddSelfLoops(loopFeatures *tensors.Tensor) error {
+	edges, err := AddSelfLoops(g.Edges, g.NumNodes)
+	if err != nil {
+		return err
+	}
+	if g.EdgeFeatures != nil {
+		if err = checkLeadingDim("EdgeFeatures", g.EdgeFeatures, g.NumEdges()); err != nil {
+			return err
+		}
+		if loopFeatures == nil {
+			loopFeatures = zeroRows(g.EdgeFeatures, g.NumNodes)
+		} else if err = checkLeadingDim("loopFeatures", loopFeatures, g.NumNodes); err != nil {
+			return err
+		}
+		features, err := ConcatenateRows(g.EdgeFeatures, loopFeatures)
+		if err != nil {
+			return err
+		}
+		g.EdgeFeatures = features
+	}
+	g.Edges = edges
+	return nil
+}
+
+// zeroRows returns a zero tensor with the dtype and inner dimensions of t, and numRows in the first axis.
+func zeroRows(t *tensors.Tensor, numRows int) *tensors.Tensor {
+	dims := t.Shape().Clone().Dimensions
+	dims[0] = numRows
+	return tensors.FromShape(shapes.Make(t.DType(), dims...))
+}
+
+// RemoveSelfLoops returns the edges without the self-loops (edges i->i), and the indices shaped [numKeptEdges]Int32
+// of the kept edges in the input, to carry edge features over.
+//
+// It returns an error if all edges are self-loops, since empty tensors are not supported.
+func RemoveSelfLoops(edges *tensors.Tensor) (keptEdges, edgeIndices *tensors.Tensor, err error) {
+	if err = checkEdges(edges); err != nil {
+		return nil, nil, err
+	}
+	numEdges := edges.Shape().Dimensions[1]
+	var kept []int32
+	tensors.ConstFlatData(edges, func(flat []int32) {
+		for edgeIdx := range numEdges {
+			if flat[edgeIdx] != flat[numEdges+edgeIdx] {
+				kept = append(kept, int32(edgeIdx))
+			}
+		}
+	})
+	if len(kept) == 0 {
+		return nil, nil, fmt.Errorf("all %d edges are self-loops", numEdges)
+	}
+	return selectEdges(edges, kept), tensors.FromValue(kept), nil
+}
+
+// RemoveSelfLoops removes the self-loops of the graph, and the corresponding EdgeFeatures.
+func (g *Graph) RemoveSelfLoops() error {
+	if err := checkLeadingDim("EdgeFeatures", g.EdgeFeatures, g.NumEdges()); err != nil {
+		return err
+	}
+	edges, edgeIndices, err := RemoveSelfLoops(g.Edges)
+	if err != nil {
+		return err
+	}
+	if g.EdgeFeatures != nil {
+		g.EdgeFeatures = GatherRows(g.EdgeFeatures, tensors.CopyFlatData[int32](edgeIndices))
+	}
+	g.Edges = edges
+	return nil
+}
+
+// RemoveIsolatedNodes removes the isolated nodes and re-indexes the remaining ones, preserving their order.
+//
+// As in PyG, a node is isolated if it is not connected to any other node: nodes with only self-loops are
+// also removed, along with their self-loops.
+//
+// It returns the re-indexed edges, the indices shaped [numKeptEdges]Int32 of the kept edges in the input (to carry
+// edge features over), the nodeMapping shaped [numNodes]Int32 from old to new node indices (-1 for removed nodes),
+// and the number of kept nodes.
+//
+// It returns an error if all nodes are isolated.
+func RemoveIsolatedNodes(edges *tensors.Tensor, numNodes int) (
+	keptEdges, edgeIndices, nodeMapping *tensors.Tensor, numKeptNodes int, err error) {
+	var mapping, kept []int32
+	numKeptNodes, mapping, kept, err = isolatedNodesMapping(edges, numNodes)
+	if err != nil {
+		return
+	}
+	keptEdges = selectEdges(edges, kept)
+	tensors.MutableFlatData(keptEdges, func(flat []int32) {
+		for i, nodeIdx := range flat {
+			flat[i] = mapping[nodeIdx]
+		}
+	})
+	return keptEdges, tensors.FromValue(kept), tensors.FromValue(mapping), numKeptNodes, nil
+}
+
+// isolatedNodesMapping implements RemoveIsolatedNodes, without building the output edges.
+func isolatedNodesMapping(edges *tensors.Tensor, numNodes int) (numKeptNodes int, mapping, keptEdges []int32, err error) {
+	if err = checkEdges(edges); err != nil {
+		return
+	}
+	numEdges := edges.Shape().Dimensions[1]
+	connected := make([]bool, numNodes)
+	tensors.ConstFlatData(edges, func(flat []int32) {
+		for i, nodeIdx := range flat {
+			if nodeIdx < 0 || int(nodeIdx) >= numNodes {
+				err = fmt.Errorf("edge #%d refers to node %d, but there are only %d nodes", i%numEdges, nodeIdx, numNodes)
+				return
+			}
+		}
+		for edgeIdx := range numEdges {
+			source, target := flat[edgeIdx], flat[numEdges+edgeIdx]
+			if source != target {
+				connected[source], connected[target] = true, true
+			}
+		}
+		for edgeIdx := range numEdges {
+			if connected[flat[edgeIdx]] {
+				keptEdges = append(keptEdges, int32(edgeIdx))
+			}
+		}
+	})
+	if err != nil {
+		return
+	}
+	mapping = make([]int32, numNodes)
+	for nodeIdx, isConnected := range connected {
+		if isConnected {
+			mapping[nodeIdx] = int32(numKeptNodes)
+			numKeptNodes++
+		} else {
+			mapping[nodeIdx] = -1
+		}
+	}
+	if numKeptNodes == 0 {
+		err = fmt.Errorf("all %d nodes are isolated", numNodes)
+	}
+	return
+}
+
+// RemoveIsolatedNodes removes the isolated nodes of the graph, re-indexing the remaining ones. See the function
+// RemoveIsolatedNodes.
+//
+// NodeFeatures, Positions, EdgeFeatures and node-level Labels (shaped [NumNodes, ...]) are updated accordingly.
+func (g *Graph) RemoveIsolatedNodes() error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	edges, edgeIndices, nodeMapping, numKeptNodes, err := RemoveIsolatedNodes(g.Edges, g.NumNodes)
+	if err != nil {
+		return err
+	}
+	keptNodes := make([]int32, 0, numKeptNodes)
+	for nodeIdx, newIdx := range tensors.CopyFlatData[int32](nodeMapping) {
+		if newIdx >= 0 {
+			keptNodes = append(keptNodes, int32(nodeIdx))
+		}
+	}
+	if g.NodeFeatures != nil {
+		g.NodeFeatures = GatherRows(g.NodeFeatures, keptNodes)
+	}
+	if g.Positions != nil {
+		g.Positions = GatherRows(g.Positions, keptNodes)
+	}
+	if g.Labels != nil && checkLeadingDim("Labels", g.Labels, g.NumNodes) == nil {
+		g.Labels = GatherRows(g.Labels, keptNodes)
+	}
+	if g.EdgeFeatures != nil {
+		g.EdgeFeatures = GatherRows(g.EdgeFeatures, tensors.CopyFlatData[int32](edgeIndices))
+	}
+	g.NumNodes = numKeptNodes
+	g.Edges = edges
+	return nil
+}
@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseAndUndirected(t *testing.T) {
+	g := New(3, tensors.FromValue([][]int32{{0, 1, 1}, {1, 0, 2}}))
+	g.EdgeFeatures = tensors.FromValue([]float32{1, 2, 3})
+	require.NoError(t, g.ReverseEdges())
+	require.Equal(t, [][]int32{{1, 0, 2}, {0, 1, 1}}, g.Edges.Value())
+	require.Equal(t, []float32{1, 2, 3}, g.EdgeFeatures.Value())
+
+	undirected, err := ToUndirected(g.Edges)
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0, 1, 1, 2}, {1, 0, 2, 1}}, undirected.Value())
+
+	// Edges 0->1 and 1->0 are present in both directions, so their features are combined.
+	require.NoError(t, g.ToUndirected(ReduceSum))
+	require.Equal(t, [][]int32{{0, 1, 1, 2}, {1, 0, 2, 1}}, g.Edges.Value())
+	require.Equal(t, []float32{3, 3, 3, 3}, g.EdgeFeatures.Value())
+}
+
+func TestSelfLoops(t *testing.T) {
+	g := New(3, tensors.FromValue([][]int32{{0, 1, 1}, {1, 1, 2}}))
+	g.EdgeFeatures = tensors.FromValue([][]float32{{1}, {2}, {3}})
+	require.NoError(t, g.RemoveSelfLoops())
+	require.Equal(t, [][]int32{{0, 1}, {1, 2}}, g.Edges.Value())
+	require.Equal(t, [][]float32{{1}, {3}}, g.EdgeFeatures.Value())
+
+	require.NoError(t, g.AddSelfLoops(nil))
+	require.Equal(t, [][]int32{{0, 1, 0, 1, 2}, {1, 2, 0, 1, 2}}, g.Edges.Value())
+	require.Equal(t, [][]float32{{1}, {3}, {0}, {0}, {0}}, g.EdgeFeatures.Value())
+	require.NoError(t, g.Validate())
+
+	g2 := New(2, tensors.FromValue([][]int32{{0}, {1}}))
+	g2.EdgeFeatures = tensors.FromValue([]int32{5})
+	require.NoError(t, g2.AddSelfLoops(tensors.FromValue([]int32{7, 8})))
+	require.Equal(t, []int32{5, 7, 8}, g2.EdgeFeatures.Value())
+	require.Error(t, g2.AddSelfLoops(tensors.FromValue([]int32{7})))
+
+	_, _, err := RemoveSelfLoops(tensors.FromValue([][]int32{{0, 1}, {0, 1}}))
+	require.Error(t, err)
+}
+
+func TestRemoveIsolatedNodes(t *testing.T) {
+	// Node 1 is isolated, node 3 has only a self-loop, and node 4 has no edges.
+	g := New(5, tensors.FromValue([][]int32{{0, 3, 2, 2}, {2, 3, 0, 2}}))
+	g.NodeFeatures = tensors.FromValue([][]float32{{0}, {1}, {2}, {3}, {4}})
+	g.Positions = tensors.FromValue([][]float32{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}})
+	g.EdgeFeatures = tensors.FromValue([]int32{10, 11, 12, 13})
+	g.Labels = tensors.FromValue([]int32{0, 1, 2, 3, 4})
+
+	_, _, nodeMapping, numKeptNodes, err := RemoveIsolatedNodes(g.Edges, g.NumNodes)
+	require.NoError(t, err)
+	require.Equal(t, 2, numKeptNodes)
+	require.Equal(t, []int32{0, -1, 1, -1, -1}, nodeMapping.Value())
+
+	require.NoError(t, g.RemoveIsolatedNodes())
+	require.NoError(t, g.Validate())
+	require.Equal(t, 2, g.NumNodes)
+	require.Equal(t, [][]int32{{0, 1, 1}, {1, 0, 1}}, g.Edges.Value())
+	require.Equal(t, [][]float32{{0}, {2}}, g.NodeFeatures.Value())
+	require.Equal(t, [][]float32{{0, 0}, {2, 2}}, g.Positions.Value())
+	require.Equal(t, []int32{10, 12, 13}, g.EdgeFeatures.Value())
+	require.Equal(t, []int32{0, 2}, g.Labels.Value())
+
+	_, _, _, _, err = RemoveIsolatedNodes(tensors.FromValue([][]int32{{0}, {0}}), 2)
+	require.Error(t, err)
+	_, _, _, _, err = RemoveIsolatedNodes(tensors.FromValue([][]int32{{0}, {3}}), 2)
+	require.Error(t, err)
+}
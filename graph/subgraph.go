@@ -0,0 +1,239 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// Direction of the edges to follow when traversing a graph.
+type Direction int
+
+const (
+	// Incoming follows the edges backwards, from target to source: it finds the nodes whose messages reach the
+	// starting nodes. This is PyG's "source_to_target" flow.
+	Incoming Direction = iota
+
+	// Outgoing follows the edges from source to target.
+	Outgoing
+
+	// Both ignores the direction of the edges.
+	Both
+)
+
+// String implements fmt.Stringer.
+func (d Direction) String() string {
+	switch d {
+	case Incoming:
+		return "Incoming"
+	case Outgoing:
+		return "Outgoing"
+	case Both:
+		return "Both"
+	default:
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+}
+
+// InducedSubgraph returns the subgraph induced by the nodes selected by nodeMask: the selected nodes and all the
+// edges between them.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - nodeMask: one value per node of the graph (so len(nodeMask) is the number of nodes), true for the nodes to keep.
+//
+// It returns:
+//   - subEdges: shaped [2, numSubEdges]Int32, re-indexed to the subgraph nodes, in the original order.
+//   - nodeIDs: shaped [numSubNodes]Int32, the original indices of the kept nodes, in increasing order.
+//     Use it with GatherRows to slice the node features.
+//   - nodeMapping: shaped [numNodes]Int32, the new index of each original node, or -1 if it was not kept.
+//   - edgeMask: shaped [numEdges]Bool, true for the kept edges.
+//
+// It returns an error if the subgraph has no nodes or no edges.
+func InducedSubgraph(edges *tensors.Tensor, nodeMask []bool) (subEdges, nodeIDs, nodeMapping, edgeMask *tensors.Tensor, err error) {
+	s, err := inducedSubgraph(edges, nodeMask)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return s.edges, tensors.FromValue(s.nodeIDs), tensors.FromValue(s.nodeMapping), tensors.FromValue(s.edgeMask), nil
+}
+
+// subgraphSelection holds the result of inducedSubgraph.
+type subgraphSelection struct {
+	edges                         *tensors.Tensor
+	nodeIDs, nodeMapping, edgeIDs []int32
+	edgeMask                      []bool
+}
+
+func inducedSubgraph(edges *tensors.Tensor, nodeMask []bool) (*subgraphSelection, error) {
+	if err := checkEdges(edges); err != nil {
+		return nil, err
+	}
+	numNodes := len(nodeMask)
+	s := &subgraphSelection{nodeMapping: make([]int32, numNodes)}
+	for nodeIdx, keep := range nodeMask {
+		if keep {
+			s.nodeMapping[nodeIdx] = int32(len(s.nodeIDs))
+			s.nodeIDs = append(s.nodeIDs, int32(nodeIdx))
+		} else {
+			s.nodeMapping[nodeIdx] = -1
+		}
+	}
+	if len(s.nodeIDs) == 0 {
+		return nil, fmt.Errorf("no nodes selected for the subgraph")
+	}
+	numEdges := edges.Shape().Dimensions[1]
+	s.edgeMask = make([]bool, numEdges)
+	var err error
+	tensors.ConstFlatData(edges, func(flat []int32) {
+		for i, nodeIdx := range flat {
+			if nodeIdx < 0 || int(nodeIdx) >= numNodes {
+				err = fmt.Errorf("edge #%d refers to node %d, but there are only %d nodes", i%numEdges, nodeIdx, numNodes)
+				return
+			}
+		}
+		for edgeIdx := range numEdges {
+			if nodeMask[flat[edgeIdx]] && nodeMask[flat[numEdges+edgeIdx]] {
+				s.edgeMask[edgeIdx] = true
+				s.edgeIDs = append(s.edgeIDs, int32(edgeIdx))
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(s.edgeIDs) == 0 {
+		return nil, fmt.Errorf("no edges between the %d nodes selected for the subgraph", len(s.nodeIDs))
+	}
+	s.edges = selectEdges(edges, s.edgeIDs)
+	tensors.MutableFlatData(s.edges, func(flat []int32) {
+		for i, nodeIdx := range flat {
+			flat[i] = s.nodeMapping[nodeIdx]
+		}
+	})
+	return s, nil
+}
+
+// KHopSubgraph returns the subgraph induced by the nodes within k hops of the seeds (including the seeds),
+// following the edges in the given direction, as PyG's k_hop_subgraph.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - numNodes: number of nodes of the graph.
+//   - seeds: the starting nodes.
+//   - k: number of hops, >= 0.
+//   - direction: which edges to follow, see Direction.
+//
+// It returns the same values as InducedSubgraph. Use nodeMapping to find the new indices of the seeds.
+func KHopSubgraph(edges *tensors.Tensor, numNodes int, seeds []int32, k int, direction Direction) (
+	subEdges, nodeIDs, nodeMapping, edgeMask *tensors.Tensor, err error) {
+	nodeMask, err := kHopNodes(edges, numNodes, seeds, k, direction)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return InducedSubgraph(edges, nodeMask)
+}
+
+// kHopNodes returns the mask of the nodes within k hops of the seeds.
+func kHopNodes(edges *tensors.Tensor, numNodes int, seeds []int32, k int, direction Direction) ([]bool, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("invalid number of hops %d", k)
+	}
+	if direction < Incoming || direction > Both {
+		return nil, fmt.Errorf("invalid direction %s", direction)
+	}
+	adj, err := NewAdjacency(edges, numNodes)
+	if err != nil {
+		return nil, err
+	}
+	nodeMask := make([]bool, numNodes)
+	var frontier []int32
+	for _, seed := range seeds {
+		if seed < 0 || int(seed) >= numNodes {
+			return nil, fmt.Errorf("seed node %d out of range, there are %d nodes", seed, numNodes)
+		}
+		if !nodeMask[seed] {
+			nodeMask[seed] = true
+			frontier = append(frontier, seed)
+		}
+	}
+	for range k {
+		var next []int32
+		visit := func(neighbors []int32) {
+			for _, neighbor := range neighbors {
+				if !nodeMask[neighbor] {
+					nodeMask[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		for _, node := range frontier {
+			if direction != Outgoing {
+				visit(adj.InNeighbors(int(node)))
+			}
+			if direction != Incoming {
+				visit(adj.Neighbors(int(node)))
+			}
+		}
+		frontier = next
+	}
+	return nodeMask, nil
+}
+
+// InducedSubgraph returns a new graph induced by the nodes selected by nodeMask (shaped [NumNodes]).
+// See the function InducedSubgraph.
+//
+// NodeFeatures, Positions, EdgeFeatures and node-level Labels (shaped [NumNodes, ...]) are sliced accordingly,
+// other Labels are copied.
+func (g *Graph) InducedSubgraph(nodeMask []bool) (*Graph, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	if len(nodeMask) != g.NumNodes {
+		return nil, fmt.Errorf("nodeMask has %d values, but the graph has %d nodes", len(nodeMask), g.NumNodes)
+	}
+	s, err := inducedSubgraph(g.Edges, nodeMask)
+	if err != nil {
+		return nil, err
+	}
+	return g.sliceSubgraph(s), nil
+}
+
+// KHopSubgraph returns a new graph induced by the nodes within k hops of the seeds, and the original indices of its
+// nodes. See the function KHopSubgraph and Graph.InducedSubgraph.
+func (g *Graph) KHopSubgraph(seeds []int32, k int, direction Direction) (subgraph *Graph, nodeIDs []int32, err error) {
+	if err = g.Validate(); err != nil {
+		return nil, nil, err
+	}
+	nodeMask, err := kHopNodes(g.Edges, g.NumNodes, seeds, k, direction)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := inducedSubgraph(g.Edges, nodeMask)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.sliceSubgraph(s), s.nodeIDs, nil
+}
+
+// sliceSubgraph creates the subgraph of the selection, slicing the node and edge tensors.
+func (g *Graph) sliceSubgraph(s *subgraphSelection) *Graph {
+	subgraph := New(len(s.nodeIDs), s.edges)
+	if g.NodeFeatures != nil {
+		subgraph.NodeFeatures = GatherRows(g.NodeFeatures, s.nodeIDs)
+	}
+	if g.Positions != nil {
+		subgraph.Positions = GatherRows(g.Positions, s.nodeIDs)
+	}
+	if g.EdgeFeatures != nil {
+		subgraph.EdgeFeatures = GatherRows(g.EdgeFeatures, s.edgeIDs)
+	}
+	if g.Labels != nil {
+		if checkLeadingDim("Labels", g.Labels, g.NumNodes) == nil {
+			subgraph.Labels = GatherRows(g.Labels, s.nodeIDs)
+		} else {
+			subgraph.Labels = g.Labels.Clone()
+		}
+	}
+	return subgraph
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInducedSubgraph(t *testing.T) {
+	edges := tensors.FromValue([][]int32{{0, 1, 2, 3, 1}, {1, 2, 3, 0, 3}})
+	subEdges, nodeIDs, nodeMapping, edgeMask, err := InducedSubgraph(edges, []bool{false, true, true, true})
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0, 1, 0}, {1, 2, 2}}, subEdges.Value())
+	require.Equal(t, []int32{1, 2, 3}, nodeIDs.Value())
+	require.Equal(t, []int32{-1, 0, 1, 2}, nodeMapping.Value())
+	require.Equal(t, []bool{false, true, true, false, true}, edgeMask.Value())
+
+	g := New(4, edges)
+	g.NodeFeatures = tensors.FromValue([][]float32{{0}, {1}, {2}, {3}})
+	g.EdgeFeatures = tensors.FromValue([]int32{10, 11, 12, 13, 14})
+	g.Labels = tensors.FromScalar(int32(1))
+	subgraph, err := g.InducedSubgraph([]bool{false, true, true, true})
+	require.NoError(t, err)
+	require.NoError(t, subgraph.Validate())
+	require.Equal(t, 3, subgraph.NumNodes)
+	require.Equal(t, [][]float32{{1}, {2}, {3}}, subgraph.NodeFeatures.Value())
+	require.Equal(t, []int32{11, 12, 14}, subgraph.EdgeFeatures.Value())
+	require.Equal(t, int32(1), subgraph.Labels.Value())
+
+	_, _, _, _, err = InducedSubgraph(edges, []bool{true, false, true, false})
+	require.Error(t, err)
+	_, _, _, _, err = InducedSubgraph(edges, []bool{true, true})
+	require.Error(t, err)
+	_, err = g.InducedSubgraph([]bool{true})
+	require.Error(t, err)
+}
+
+func TestKHopSubgraph(t *testing.T) {
+	// Chain 0 -> 1 -> 2 -> 3 -> 4, plus 5 -> 2.
+	edges := tensors.FromValue([][]int32{{0, 1, 2, 3, 5}, {1, 2, 3, 4, 2}})
+	for _, tc := range []struct {
+		k         int
+		direction Direction
+		want      []int32
+	}{
+		{1, Incoming, []int32{1, 2, 5}},
+		{2, Incoming, []int32{0, 1, 2, 5}},
+		{1, Outgoing, []int32{2, 3}},
+		{1, Both, []int32{1, 2, 3, 5}},
+		{3, Both, []int32{0, 1, 2, 3, 4, 5}},
+	} {
+		_, nodeIDs, nodeMapping, _, err := KHopSubgraph(edges, 6, []int32{2}, tc.k, tc.direction)
+		require.NoError(t, err, "k=%d, direction=%s", tc.k, tc.direction)
+		require.Equal(t, tc.want, nodeIDs.Value(), "k=%d, direction=%s", tc.k, tc.direction)
+		require.GreaterOrEqual(t, nodeMapping.Value().([]int32)[2], int32(0))
+	}
+
+	g := New(6, edges)
+	g.NodeFeatures = tensors.FromValue([]float32{0, 1, 2, 3, 4, 5})
+	subgraph, nodeIDs, err := g.KHopSubgraph([]int32{2}, 1, Incoming)
+	require.NoError(t, err)
+	require.Equal(t, []int32{1, 2, 5}, nodeIDs)
+	require.Equal(t, [][]int32{{0, 2}, {1, 1}}, subgraph.Edges.Value())
+	require.Equal(t, []float32{1, 2, 5}, subgraph.NodeFeatures.Value())
+
+	// Errors: zero hops of a single node (no edges), seed out of range, negative k.
+	_, _, _, _, err = KHopSubgraph(edges, 6, []int32{2}, 0, Incoming)
+	require.Error(t, err)
+	_, _, _, _, err = KHopSubgraph(edges, 6, []int32{6}, 1, Incoming)
+	require.Error(t, err)
+	_, _, err = g.KHopSubgraph([]int32{2}, -1, Both)
+	require.Error(t, err)
+}
@@ -0,0 +1,324 @@
+package graph
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gomlx/exceptions"
+)
+
+// Transform modifies a Graph in-place, typically as a preprocessing step applied to each example of a dataset.
+//
+// Use Compose to chain transforms, and RegisterTransform / ParseTransforms to configure them by name
+// (e.g. from flags or config files). Clone the graph before applying a transform if the original is still needed.
+type Transform interface {
+	Apply(g *Graph) error
+}
+
+// TransformFunc is a function that implements the Transform interface.
+type TransformFunc func(g *Graph) error
+
+// Apply implements Transform.
+func (fn TransformFunc) Apply(g *Graph) error {
+	return fn(g)
+}
+
+// composedTransform is returned by Compose.
+type composedTransform []Transform
+
+// Apply implements Transform.
+func (transforms composedTransform) Apply(g *Graph) error {
+	for i, transform := range transforms {
+		if err := transform.Apply(g); err != nil {
+			return fmt.Errorf("transform #%d (%T): %w", i, transform, err)
+		}
+	}
+	return nil
+}
+
+// Compose returns a Transform that applies the given transforms in order, stopping at the first error.
+func Compose(transforms ...Transform) Transform {
+	return composedTransform(slices.Clone(transforms))
+}
+
+// TransformAll applies the transform to each of the graphs, in-place.
+// It returns an error with the index of the first graph that fails.
+func TransformAll(transform Transform, graphs []*Graph) error {
+	for i, g := range graphs {
+		if err := transform.Apply(g); err != nil {
+			return fmt.Errorf("graph #%d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// TransformParams are the parameters used to create a registered transform, see RegisterTransform.
+type TransformParams map[string]string
+
+// CheckKeys returns an error if there are parameters other than the allowed ones.
+func (p TransformParams) CheckKeys(allowed ...string) error {
+	for _, key := range slices.Sorted(maps.Keys(p)) {
+		if !slices.Contains(allowed, key) {
+			return fmt.Errorf("unknown parameter %q, valid parameters are %q", key, allowed)
+		}
+	}
+	return nil
+}
+
+// String returns the value of the parameter key, or defaultValue if it is not set.
+func (p TransformParams) String(key, defaultValue string) string {
+	if value, found := p[key]; found {
+		return value
+	}
+	return defaultValue
+}
+
+// Float returns the value of the parameter key parsed as a float, or defaultValue if it is not set.
+func (p TransformParams) Float(key string, defaultValue float64) (float64, error) {
+	value, found := p[key]
+	if !found {
+		return defaultValue, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid float value %q for parameter %q: %w", value, key, err)
+	}
+	return f, nil
+}
+
+// Int returns the value of the parameter key parsed as an int, or defaultValue if it is not set.
+func (p TransformParams) Int(key string, defaultValue int) (int, error) {
+	value, found := p[key]
+	if !found {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid int value %q for parameter %q: %w", value, key, err)
+	}
+	return i, nil
+}
+
+// Bool returns the value of the parameter key parsed as a bool, or defaultValue if it is not set.
+func (p TransformParams) Bool(key string, defaultValue bool) (bool, error) {
+	value, found := p[key]
+	if !found {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid bool value %q for parameter %q: %w", value, key, err)
+	}
+	return b, nil
+}
+
+// TransformFactory creates a Transform from its parameters. It should return an error for unknown or invalid
+// parameters (see TransformParams.CheckKeys).
+type TransformFactory func(params TransformParams) (Transform, error)
+
+var (
+	transformsMu       sync.RWMutex
+	transformFactories = make(map[string]TransformFactory)
+)
+
+// RegisterTransform registers a TransformFactory under the given name, so it can be created with NewTransform or
+// ParseTransforms. It is usually called in an init() function, and it panics if the name is already registered.
+//
+// The graph package registers "to_undirected" (parameter "reduce", default "sum"), "add_self_loops",
+// "remove_self_loops", "reverse_edges", "remove_isolated_nodes", "sort_edges", "normalize_features" and
+// "ppr_diffusion" (see PPRDiffusionTransform).
+func RegisterTransform(name string, factory TransformFactory) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	if _, found := transformFactories[name]; found {
+		exceptions.Panicf("RegisterTransform: transform %q already registered", name)
+	}
+	transformFactories[name] = factory
+}
+
+// RegisteredTransforms returns the sorted names of the registered transforms.
+func RegisteredTransforms() []string {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	return slices.Sorted(maps.Keys(transformFactories))
+}
+
+// NewTransform creates the registered transform with the given name and parameters.
+func NewTransform(name string, params TransformParams) (Transform, error) {
+	transformsMu.RLock()
+	factory, found := transformFactories[name]
+	transformsMu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unknown transform %q, registered transforms are %q", name, RegisteredTransforms())
+	}
+	if params == nil {
+		params = TransformParams{}
+	}
+	transform, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("transform %q: %w", name, err)
+	}
+	return transform, nil
+}
+
+// ParseTransforms creates a pipeline of registered transforms from a specification, for instance from a flag.
+//
+// The specification is a comma-separated list of transform names, each optionally followed by its parameters
+// in parenthesis as comma-separated key=value pairs. E.g.:
+//
+//	"radius_graph(radius=1.5),add_self_loops,normalize_features"
+//
+// The transforms are composed in order with Compose.
+func ParseTransforms(spec string) (Transform, error) {
+	var transforms []Transform
+	for _, item := range splitTopLevel(spec) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, params := item, TransformParams{}
+		if open := strings.Index(item, "("); open >= 0 {
+			if !strings.HasSuffix(item, ")") {
+				return nil, fmt.Errorf("invalid transform %q in %q: missing closing parenthesis", item, spec)
+			}
+			name = strings.TrimSpace(item[:open])
+			for _, param := range strings.Split(item[open+1:len(item)-1], ",") {
+				if strings.TrimSpace(param) == "" {
+					continue
+				}
+				key, value, found := strings.Cut(param, "=")
+				if !found {
+					return nil, fmt.Errorf("invalid parameter %q of transform %q: expected key=value", param, name)
+				}
+				params[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+		transform, err := NewTransform(name, params)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, transform)
+	}
+	return Compose(transforms...), nil
+}
+
+// splitTopLevel splits the spec on commas that are not within parenthesis.
+func splitTopLevel(spec string) []string {
+	var parts []string
+	var depth, start int
+	for i, r := range spec {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, spec[start:])
+}
+
+// NormalizeFeatures returns a Transform that normalizes the NodeFeatures of each node to sum up to 1, as PyG's
+// NormalizeFeatures. Nodes whose features sum to 0 are left unchanged. NodeFeatures must be Float32 or Float64,
+// and graphs without NodeFeatures are left unchanged.
+func NormalizeFeatures() Transform {
+	return TransformFunc(func(g *Graph) error {
+		if g.NodeFeatures == nil {
+			return nil
+		}
+		if err := checkLeadingDim("NodeFeatures", g.NodeFeatures, g.NumNodes); err != nil {
+			return err
+		}
+		features := g.NodeFeatures.Clone()
+		rowSize := features.Shape().Size() / g.NumNodes
+		var err error
+		features.MutableFlatData(func(flatAny any) {
+			switch flat := flatAny.(type) {
+			case []float32:
+				normalizeRows(flat, rowSize)
+			case []float64:
+				normalizeRows(flat, rowSize)
+			default:
+				err = fmt.Errorf("NormalizeFeatures requires Float32 or Float64 node features, got %s", g.NodeFeatures.DType())
+			}
+		})
+		if err != nil {
+			return err
+		}
+		g.NodeFeatures = features
+		return nil
+	})
+}
+
+func normalizeRows[T float32 | float64](flat []T, rowSize int) {
+	for start := 0; start < len(flat); start += rowSize {
+		row := flat[start : start+rowSize]
+		var sum T
+		for _, value := range row {
+			sum += value
+		}
+		if sum == 0 {
+			continue
+		}
+		for i := range row {
+			row[i] /= sum
+		}
+	}
+}
+
+// noParamsTransform returns a TransformFactory for a transform without parameters.
+func noParamsTransform(transform Transform) TransformFactory {
+	return func(params TransformParams) (Transform, error) {
+		if err := params.CheckKeys(); err != nil {
+			return nil, err
+		}
+		return transform, nil
+	}
+}
+
+func init() {
+	RegisterTransform("to_undirected", func(params TransformParams) (Transform, error) {
+		if err := params.CheckKeys("reduce"); err != nil {
+			return nil, err
+		}
+		reduce, err := ParseReduction(params.String("reduce", "sum"))
+		if err != nil {
+			return nil, err
+		}
+		return TransformFunc(func(g *Graph) error { return g.ToUndirected(reduce) }), nil
+	})
+	RegisterTransform("add_self_loops", noParamsTransform(TransformFunc(func(g *Graph) error {
+		return g.AddSelfLoops(nil)
+	})))
+	RegisterTransform("remove_self_loops", noParamsTransform(TransformFunc((*Graph).RemoveSelfLoops)))
+	RegisterTransform("reverse_edges", noParamsTransform(TransformFunc((*Graph).ReverseEdges)))
+	RegisterTransform("remove_isolated_nodes", noParamsTransform(TransformFunc((*Graph).RemoveIsolatedNodes)))
+	RegisterTransform("sort_edges", noParamsTransform(TransformFunc((*Graph).SortEdgesBySource)))
+	RegisterTransform("normalize_features", noParamsTransform(NormalizeFeatures()))
+	RegisterTransform("ppr_diffusion", func(params TransformParams) (Transform, error) {
+		if err := params.CheckKeys("alpha", "epsilon", "top_k"); err != nil {
+			return nil, err
+		}
+		alpha, err := params.Float("alpha", 0.15)
+		if err != nil {
+			return nil, err
+		}
+		epsilon, err := params.Float("epsilon", 1e-4)
+		if err != nil {
+			return nil, err
+		}
+		topK, err := params.Int("top_k", 32)
+		if err != nil {
+			return nil, err
+		}
+		return PPRDiffusionTransform(alpha, epsilon, topK), nil
+	})
+}
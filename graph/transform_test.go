@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompose(t *testing.T) {
+	var calls []string
+	record := func(name string) Transform {
+		return TransformFunc(func(g *Graph) error {
+			calls = append(calls, name)
+			return nil
+		})
+	}
+	failing := TransformFunc(func(g *Graph) error { return fmt.Errorf("failed") })
+
+	g := New(2, tensors.FromValue([][]int32{{0}, {1}}))
+	require.NoError(t, Compose(record("a"), record("b")).Apply(g))
+	require.Equal(t, []string{"a", "b"}, calls)
+
+	calls = nil
+	require.ErrorContains(t, Compose(record("a"), failing, record("c")).Apply(g), "transform #1")
+	require.Equal(t, []string{"a"}, calls)
+
+	graphs := []*Graph{
+		New(2, tensors.FromValue([][]int32{{0}, {1}})),
+		New(3, tensors.FromValue([][]int32{{0}, {2}})),
+	}
+	require.NoError(t, TransformAll(Compose(TransformFunc((*Graph).ReverseEdges)), graphs))
+	require.Equal(t, [][]int32{{1}, {0}}, graphs[0].Edges.Value())
+	require.Equal(t, [][]int32{{2}, {0}}, graphs[1].Edges.Value())
+}
+
+func TestTransformRegistry(t *testing.T) {
+	require.Subset(t, RegisteredTransforms(), []string{"to_undirected", "add_self_loops", "remove_self_loops",
+		"reverse_edges", "remove_isolated_nodes", "sort_edges", "normalize_features", "ppr_diffusion"})
+	require.Panics(t, func() { RegisterTransform("sort_edges", nil) })
+
+	transform, err := ParseTransforms("remove_self_loops, to_undirected(reduce=max), add_self_loops")
+	require.NoError(t, err)
+	g := New(3, tensors.FromValue([][]int32{{0, 1, 2}, {1, 1, 0}}))
+	g.EdgeFeatures = tensors.FromValue([]float32{1, 2, 3})
+	require.NoError(t, transform.Apply(g))
+	require.Equal(t, [][]int32{{0, 0, 1, 2, 0, 1, 2}, {1, 2, 0, 0, 0, 1, 2}}, g.Edges.Value())
+	require.Equal(t, []float32{1, 3, 1, 3, 0, 0, 0}, g.EdgeFeatures.Value())
+
+	for _, spec := range []string{"unknown", "to_undirected(reduce=avg)", "sort_edges(x=1)", "to_undirected(reduce",
+		"to_undirected(reduce)"} {
+		_, err = ParseTransforms(spec)
+		require.Error(t, err, "spec=%q", spec)
+	}
+
+	params := TransformParams{"f": "1.5", "i": "3", "b": "true", "bad": "x"}
+	f, err := params.Float("f", 0)
+	require.NoError(t, err)
+	require.Equal(t, 1.5, f)
+	i, err := params.Int("i", 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, i)
+	b, err := params.Bool("b", false)
+	require.NoError(t, err)
+	require.True(t, b)
+	i, err = params.Int("missing", 7)
+	require.NoError(t, err)
+	require.Equal(t, 7, i)
+	_, err = params.Float("bad", 0)
+	require.Error(t, err)
+}
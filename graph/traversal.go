@@ -0,0 +1,316 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// WeaklyConnectedComponents finds the connected components of the graph, ignoring the direction of the edges.
+//
+// It uses a union-find over the edges, without building an adjacency, so it runs in near linear time and
+// O(numNodes) extra memory, for graphs with tens of millions of edges.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - numNodes: number of nodes of the graph.
+//
+// It returns the component of each node shaped [numNodes]Int32, and the number of components. Components are
+// numbered in the order of their smallest node, so node 0 is always in component 0, and isolated nodes are
+// components of their own.
+func WeaklyConnectedComponents(edges *tensors.Tensor, numNodes int) (components *tensors.Tensor, numComponents int, err error) {
+	if err = checkEdges(edges); err != nil {
+		return nil, 0, err
+	}
+	if numNodes <= 0 {
+		return nil, 0, fmt.Errorf("invalid number of nodes %d", numNodes)
+	}
+	parent := rangeIndices(0, int32(numNodes))
+	size := make([]int32, numNodes)
+	find := func(node int32) int32 {
+		for parent[node] != node {
+			// Path halving.
+			parent[node] = parent[parent[node]]
+			node = parent[node]
+		}
+		return node
+	}
+	numEdges := edges.Shape().Dimensions[1]
+	tensors.ConstFlatData(edges, func(flat []int32) {
+		for i, nodeIdx := range flat {
+			if nodeIdx < 0 || int(nodeIdx) >= numNodes {
+				err = fmt.Errorf("edge #%d refers to node %d, but there are only %d nodes", i%numEdges, nodeIdx, numNodes)
+				return
+			}
+		}
+		for edgeIdx := range numEdges {
+			a, b := find(flat[edgeIdx]), find(flat[numEdges+edgeIdx])
+			if a == b {
+				continue
+			}
+			// Union by size.
+			if size[a] < size[b] {
+				a, b = b, a
+			}
+			parent[b] = a
+			size[a] += size[b] + 1
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	for node := range int32(numNodes) {
+		parent[node] = find(node)
+	}
+	numComponents = renumberComponents(parent)
+	return tensors.FromValue(parent), numComponents, nil
+}
+
+// StronglyConnectedComponents finds the strongly connected components of the graph: the maximal sets of nodes
+// where every node can reach every other node following the edges.
+//
+// It uses an iterative version of Tarjan's algorithm, so it runs in O(numNodes + numEdges) without recursion,
+// for graphs with tens of millions of edges.
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - numNodes: number of nodes of the graph.
+//
+// It returns the component of each node shaped [numNodes]Int32, and the number of components. Components are
+// numbered in the order of their smallest node.
+func StronglyConnectedComponents(edges *tensors.Tensor, numNodes int) (components *tensors.Tensor, numComponents int, err error) {
+	csr, err := ToCSR(edges, numNodes)
+	if err != nil {
+		return nil, 0, err
+	}
+	type frame struct {
+		node, pos int32
+	}
+	index := make([]int32, numNodes)
+	lowLink := make([]int32, numNodes)
+	component := make([]int32, numNodes)
+	for i := range index {
+		index[i], component[i] = -1, -1
+	}
+	onStack := make([]bool, numNodes)
+	var stack []int32
+	var frames []frame
+	var nextIndex, nextComponent int32
+	visit := func(node int32) {
+		index[node], lowLink[node] = nextIndex, nextIndex
+		nextIndex++
+		stack = append(stack, node)
+		onStack[node] = true
+		frames = append(frames, frame{node, csr.Ptr[node]})
+	}
+	for root := range int32(numNodes) {
+		if index[root] >= 0 {
+			continue
+		}
+		visit(root)
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			node := f.node
+			if f.pos < csr.Ptr[node+1] {
+				neighbor := csr.Indices[f.pos]
+				f.pos++
+				if index[neighbor] < 0 {
+					visit(neighbor)
+				} else if onStack[neighbor] {
+					lowLink[node] = min(lowLink[node], index[neighbor])
+				}
+				continue
+			}
+
+			// All neighbors visited: close the node.
+			frames = frames[:len(frames)-1]
+			if lowLink[node] == index[node] {
+				for {
+					member := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[member] = false
+					component[member] = nextComponent
+					if member == node {
+						break
+					}
+				}
+				nextComponent++
+			}
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].node
+				lowLink[parent] = min(lowLink[parent], lowLink[node])
+			}
+		}
+	}
+	numComponents = renumberComponents(component)
+	return tensors.FromValue(component), numComponents, nil
+}
+
+// renumberComponents renumbers, in place, the component ids (in [0, numNodes)) in the order of their smallest node,
+// and returns the number of components.
+func renumberComponents(components []int32) int {
+	newID := make([]int32, len(components))
+	for i := range newID {
+		newID[i] = -1
+	}
+	var numComponents int32
+	for node, component := range components {
+		if newID[component] < 0 {
+			newID[component] = numComponents
+			numComponents++
+		}
+		components[node] = newID[component]
+	}
+	return int(numComponents)
+}
+
+// traversal holds the adjacency used by BFSOrder, DFSOrder and HopDistances.
+type traversal struct {
+	adj       *Adjacency
+	direction Direction
+	visited   []bool
+}
+
+func newTraversal(edges *tensors.Tensor, numNodes int, sources []int32, direction Direction) (*traversal, error) {
+	if direction < Incoming || direction > Both {
+		return nil, fmt.Errorf("invalid direction %s", direction)
+	}
+	adj, err := NewAdjacency(edges, numNodes)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no source nodes given")
+	}
+	for _, source := range sources {
+		if source < 0 || int(source) >= numNodes {
+			return nil, fmt.Errorf("source node %d out of range, there are %d nodes", source, numNodes)
+		}
+	}
+	return &traversal{adj: adj, direction: direction, visited: make([]bool, numNodes)}, nil
+}
+
+// neighbors returns the neighbors of node to follow: for Both, the outgoing neighbors followed by the incoming ones.
+func (t *traversal) neighbors(node int32) (first, second []int32) {
+	switch t.direction {
+	case Incoming:
+		return t.adj.InNeighbors(int(node)), nil
+	case Outgoing:
+		return t.adj.Neighbors(int(node)), nil
+	default:
+		return t.adj.Neighbors(int(node)), t.adj.InNeighbors(int(node))
+	}
+}
+
+// bfs visits the nodes reachable from the sources in breadth-first order, calling fn with each node and its
+// hop distance to the closest source.
+func (t *traversal) bfs(sources []int32, fn func(node, distance int32)) {
+	var frontier, next []int32
+	for _, source := range sources {
+		if !t.visited[source] {
+			t.visited[source] = true
+			frontier = append(frontier, source)
+			fn(source, 0)
+		}
+	}
+	for distance := int32(1); len(frontier) > 0; distance++ {
+		next = next[:0]
+		for _, node := range frontier {
+			first, second := t.neighbors(node)
+			for _, neighbors := range [2][]int32{first, second} {
+				for _, neighbor := range neighbors {
+					if !t.visited[neighbor] {
+						t.visited[neighbor] = true
+						next = append(next, neighbor)
+						fn(neighbor, distance)
+					}
+				}
+			}
+		}
+		frontier, next = next, frontier
+	}
+}
+
+// BFSOrder returns the nodes reachable from the sources in breadth-first order, shaped [numVisited]Int32.
+//
+// The sources come first (in the given order, without duplicates), and the neighbors of each node are visited in
+// increasing order (for Both, the outgoing neighbors before the incoming ones).
+//
+// Args:
+//   - edges: shaped [2, numEdges]Int32.
+//   - numNodes: number of nodes of the graph.
+//   - sources: the starting nodes.
+//   - direction: which edges to follow, see Direction.
+func BFSOrder(edges *tensors.Tensor, numNodes int, sources []int32, direction Direction) (*tensors.Tensor, error) {
+	t, err := newTraversal(edges, numNodes, sources, direction)
+	if err != nil {
+		return nil, err
+	}
+	var order []int32
+	t.bfs(sources, func(node, _ int32) { order = append(order, node) })
+	return tensors.FromValue(order), nil
+}
+
+// HopDistances returns the number of hops (edges in the shortest path) from the closest source to each node, shaped
+// [numNodes]Int32, with -1 for the nodes that can't be reached.
+//
+// See BFSOrder for the arguments.
+func HopDistances(edges *tensors.Tensor, numNodes int, sources []int32, direction Direction) (*tensors.Tensor, error) {
+	t, err := newTraversal(edges, numNodes, sources, direction)
+	if err != nil {
+		return nil, err
+	}
+	distances := make([]int32, numNodes)
+	for i := range distances {
+		distances[i] = -1
+	}
+	t.bfs(sources, func(node, distance int32) { distances[node] = distance })
+	return tensors.FromValue(distances), nil
+}
+
+// DFSOrder returns the nodes reachable from the sources in depth-first pre-order, shaped [numVisited]Int32.
+//
+// A depth-first search is started from each source in turn (skipping the ones already visited), and the neighbors of
+// each node are visited in increasing order (for Both, the outgoing neighbors before the incoming ones), as in a
+// recursive implementation. It is iterative, so it doesn't overflow the stack on large graphs.
+//
+// See BFSOrder for the arguments.
+func DFSOrder(edges *tensors.Tensor, numNodes int, sources []int32, direction Direction) (*tensors.Tensor, error) {
+	t, err := newTraversal(edges, numNodes, sources, direction)
+	if err != nil {
+		return nil, err
+	}
+	type frame struct {
+		first, second []int32
+	}
+	var order []int32
+	var frames []frame
+	visit := func(node int32) {
+		t.visited[node] = true
+		order = append(order, node)
+		first, second := t.neighbors(node)
+		frames = append(frames, frame{first, second})
+	}
+	for _, source := range sources {
+		if t.visited[source] {
+			continue
+		}
+		visit(source)
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			if len(f.first) == 0 {
+				f.first, f.second = f.second, nil
+			}
+			if len(f.first) == 0 {
+				frames = frames[:len(frames)-1]
+				continue
+			}
+			neighbor := f.first[0]
+			f.first = f.first[1:]
+			if !t.visited[neighbor] {
+				visit(neighbor)
+			}
+		}
+	}
+	return tensors.FromValue(order), nil
+}
@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectedComponents(t *testing.T) {
+	// 0 -> 1 -> 2 -> 0 is a cycle, 2 -> 3 -> 4 and 4 -> 3; 5 is isolated; 6 -> 7.
+	edges := tensors.FromValue([][]int32{{0, 1, 2, 2, 3, 4, 6}, {1, 2, 0, 3, 4, 3, 7}})
+	components, numComponents, err := WeaklyConnectedComponents(edges, 8)
+	require.NoError(t, err)
+	require.Equal(t, 3, numComponents)
+	require.Equal(t, []int32{0, 0, 0, 0, 0, 1, 2, 2}, components.Value())
+
+	components, numComponents, err = StronglyConnectedComponents(edges, 8)
+	require.NoError(t, err)
+	require.Equal(t, 5, numComponents)
+	require.Equal(t, []int32{0, 0, 0, 1, 1, 2, 3, 4}, components.Value())
+
+	_, _, err = WeaklyConnectedComponents(edges, 5)
+	require.Error(t, err)
+	_, _, err = StronglyConnectedComponents(edges, 5)
+	require.Error(t, err)
+
+	// Compare with the reachability given by HopDistances, on a random graph.
+	const numNodes = 80
+	rng := rand.New(rand.NewPCG(42, 0))
+	edges = randomEdges(rng, 120, numNodes)
+	weak, _, err := WeaklyConnectedComponents(edges, numNodes)
+	require.NoError(t, err)
+	strong, _, err := StronglyConnectedComponents(edges, numNodes)
+	require.NoError(t, err)
+	weakIDs, strongIDs := weak.Value().([]int32), strong.Value().([]int32)
+	reachable := make([][]int32, numNodes)
+	undirected := make([][]int32, numNodes)
+	for node := range int32(numNodes) {
+		distances, err := HopDistances(edges, numNodes, []int32{node}, Outgoing)
+		require.NoError(t, err)
+		reachable[node] = distances.Value().([]int32)
+		distances, err = HopDistances(edges, numNodes, []int32{node}, Both)
+		require.NoError(t, err)
+		undirected[node] = distances.Value().([]int32)
+	}
+	for a := range numNodes {
+		for b := range numNodes {
+			require.Equal(t, undirected[a][b] >= 0, weakIDs[a] == weakIDs[b], "nodes %d and %d", a, b)
+			require.Equal(t, reachable[a][b] >= 0 && reachable[b][a] >= 0, strongIDs[a] == strongIDs[b],
+				"nodes %d and %d", a, b)
+		}
+	}
+
+	// Long chains don't overflow.
+	const chainLength = 200_000
+	sources, targets := rangeIndices(0, chainLength-1), rangeIndices(1, chainLength)
+	edges = tensors.FromValue([][]int32{append(sources, chainLength-1), append(targets, 0)})
+	_, numComponents, err = StronglyConnectedComponents(edges, chainLength)
+	require.NoError(t, err)
+	require.Equal(t, 1, numComponents)
+}
+
+func TestTraversals(t *testing.T) {
+	// 0 -> 1, 0 -> 2, 1 -> 3, 2 -> 3, 3 -> 4, 5 -> 0; 6 is isolated.
+	edges := tensors.FromValue([][]int32{{0, 0, 1, 2, 3, 5}, {1, 2, 3, 3, 4, 0}})
+	for _, tc := range []struct {
+		sources   []int32
+		direction Direction
+		bfs, dfs  []int32
+		distances []int32
+	}{
+		{[]int32{0}, Outgoing, []int32{0, 1, 2, 3, 4}, []int32{0, 1, 3, 4, 2}, []int32{0, 1, 1, 2, 3, -1, -1}},
+		{[]int32{3}, Incoming, []int32{3, 1, 2, 0, 5}, []int32{3, 1, 0, 5, 2}, []int32{2, 1, 1, 0, -1, 3, -1}},
+		{[]int32{4}, Both, []int32{4, 3, 1, 2, 0, 5}, []int32{4, 3, 1, 0, 2, 5}, []int32{3, 2, 2, 1, 0, 4, -1}},
+		{[]int32{6, 4, 4}, Outgoing, []int32{6, 4}, []int32{6, 4}, []int32{-1, -1, -1, -1, 0, -1, 0}},
+	} {
+		bfs, err := BFSOrder(edges, 7, tc.sources, tc.direction)
+		require.NoError(t, err)
+		require.Equal(t, tc.bfs, bfs.Value(), "sources=%v, direction=%s", tc.sources, tc.direction)
+		dfs, err := DFSOrder(edges, 7, tc.sources, tc.direction)
+		require.NoError(t, err)
+		require.Equal(t, tc.dfs, dfs.Value(), "sources=%v, direction=%s", tc.sources, tc.direction)
+		distances, err := HopDistances(edges, 7, tc.sources, tc.direction)
+		require.NoError(t, err)
+		require.Equal(t, tc.distances, distances.Value(), "sources=%v, direction=%s", tc.sources, tc.direction)
+	}
+
+	_, err := BFSOrder(edges, 7, nil, Outgoing)
+	require.Error(t, err)
+	_, err = DFSOrder(edges, 7, []int32{7}, Outgoing)
+	require.Error(t, err)
+	_, err = HopDistances(edges, 7, []int32{0}, Direction(5))
+	require.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/gomlx/gomlx/types/tensors"
+)
+
+// Triplets enumerates the pairs of consecutive edges k->j->i, with k != i, as used for directional message
+// passing (e.g. DimeNet), where the message of edge j->i aggregates the messages from all edges k->j.
+//
+// The edges tensor is shaped [2, numEdges]Int32, where edge_e connects source node edges[0][e] to target
+// node edges[1][e] (as returned by geometry.RadiusEdges). The edges don't need to be sorted: they are
+// grouped by source with a counting sort, in O(numEdges + numNodes + numTriplets).
+//
+// It returns two tensors shaped [numTriplets]Int32, with the indices (in the edges tensor) of the edges k->j and
+// j->i of each triplet. The triplets are ordered by the edge k->j, and then by the edge j->i.
+//
+// If no triplets are found, it returns an error.
+func Triplets(edgesT *tensors.Tensor) (edgeKJ, edgeJI *tensors.Tensor, err error) {
+	err = checkEdges(edgesT)
+	if err != nil {
+		return nil, nil, err
+	}
+	numEdges := edgesT.Shape().Dimensions[1]
+	var kj, ji []int32
+	tensors.ConstFlatData(edgesT, func(flat []int32) {
+		sources, targets := flat[:numEdges], flat[numEdges:]
+		var numNodes int
+		for _, nodeIdx := range flat {
+			if nodeIdx < 0 {
+				err = fmt.Errorf("invalid negative node index %d in edges", nodeIdx)
+				return
+			}
+			numNodes = max(numNodes, int(nodeIdx)+1)
+		}
+
+		// Counting sort of the edges by source: the outgoing edges of node n are
+		// bySource[offsets[n]:offsets[n+1]], in the order they appear in edges.
+		offsets := make([]int, numNodes+1)
+		for _, source := range sources {
+			offsets[source+1]++
+		}
+		for nodeIdx := range numNodes {
+			offsets[nodeIdx+1] += offsets[nodeIdx]
+		}
+		bySource := make([]int32, numEdges)
+		next := make([]int, numNodes)
+		copy(next, offsets[:numNodes])
+		for edgeIdx, source := range sources {
+			bySource[next[source]] = int32(edgeIdx)
+			next[source]++
+		}
+
+		// Count first to preallocate: this is an upper bound, since it includes the k == i pairs skipped below.
+		var numTriplets int
+		for edgeIdx := range numEdges {
+			j := targets[edgeIdx]
+			numTriplets += offsets[j+1] - offsets[j]
+		}
+		kj = make([]int32, 0, numTriplets)
+		ji = make([]int32, 0, numTriplets)
+		for edgeIdx := range numEdges {
+			k, j := sources[edgeIdx], targets[edgeIdx]
+			for _, outEdgeIdx := range bySource[offsets[j]:offsets[j+1]] {
+				if targets[outEdgeIdx] == k {
+					continue
+				}
+				kj = append(kj, int32(edgeIdx))
+				ji = append(ji, outEdgeIdx)
+			}
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(kj) == 0 {
+		return nil, nil, fmt.Errorf("no triplets found in the %d edges", numEdges)
+	}
+	return tensors.FromValue(kj), tensors.FromValue(ji), nil
+}
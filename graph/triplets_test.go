@@ -0,0 +1,25 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/gomlx/gomlx/types/tensors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriplets(t *testing.T) {
+	// Edges: 0->1, 1->2, 2->0, 1->0
+	edges := tensors.FromValue([][]int32{{0, 1, 2, 1}, {1, 2, 0, 0}})
+	edgeKJ, edgeJI, err := Triplets(edges)
+	require.NoError(t, err)
+	// The triplet 1->0->1 is excluded, since k == i.
+	require.Equal(t, []int32{0, 1, 2}, edgeKJ.Value())
+	require.Equal(t, []int32{1, 2, 0}, edgeJI.Value())
+
+	// No triplets.
+	_, _, err = Triplets(tensors.FromValue([][]int32{{0, 1}, {1, 0}}))
+	require.Error(t, err)
+
+	_, _, err = Triplets(tensors.FromValue([][]int32{{0, -1}, {1, 0}}))
+	require.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"math/rand/v2"
 	"testing"
 
 	"github.com/gomlx/gomlx/types/tensors"
@@ -44,3 +45,71 @@ func TestUnionEdges(t *testing.T) {
 	_, err = UnionEdges(invalidDType)
 	require.Error(t, err, "Test Case 6 Failed: Expected error for invalid dtype")
 }
+
+func TestUnionEdgesDeterministic(t *testing.T) {
+	// Output is sorted without calling SortEdgesBySource, and nil inputs are skipped.
+	result, err := UnionEdges(tensors.FromValue([][]int32{{3, 0, 1}, {0, 2, 1}}), nil,
+		tensors.FromValue([][]int32{{1, 0}, {1, 2}}))
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0, 1, 3}, {2, 1, 0}}, result.Value())
+
+	// No edges at all.
+	_, err = UnionEdges(nil, nil)
+	require.Error(t, err)
+
+	// Large inputs (radix sort path) and the parallel version match a simple map based union.
+	rng := rand.New(rand.NewPCG(42, 0))
+	inputs := make([]*tensors.Tensor, 3)
+	want := make(map[[2]int32]bool)
+	for i := range inputs {
+		inputs[i] = randomEdges(rng, 5000, 100_000)
+		sources, targets := New(100_000, inputs[i]).Sources(), New(100_000, inputs[i]).Targets()
+		for j := range sources {
+			want[[2]int32{sources[j], targets[j]}] = true
+		}
+	}
+	result, err = UnionEdges(inputs...)
+	require.NoError(t, err)
+	require.Equal(t, len(want), result.Shape().Dimensions[1])
+	edges := result.Value().([][]int32)
+	for j := range edges[0] {
+		require.True(t, want[[2]int32{edges[0][j], edges[1][j]}])
+		if j > 0 {
+			require.True(t, edges[0][j-1] < edges[0][j] || (edges[0][j-1] == edges[0][j] && edges[1][j-1] < edges[1][j]))
+		}
+	}
+	for _, numWorkers := range []int{0, 1, 3, 16} {
+		parallelResult, err := UnionEdgesParallel(numWorkers, inputs...)
+		require.NoError(t, err)
+		require.Equal(t, edges, parallelResult.Value(), "numWorkers=%d", numWorkers)
+	}
+}
+
+// randomEdges returns numEdges random edges between numNodes nodes. About 10% of the edges are duplicates.
+func randomEdges(rng *rand.Rand, numEdges, numNodes int) *tensors.Tensor {
+	flat := make([]int32, 2*numEdges)
+	for i := range flat {
+		flat[i] = int32(rng.IntN(numNodes))
+	}
+	// Force some duplicates, each copying a random earlier edge.
+	for i := 0; i < numEdges/10; i++ {
+		j := rng.IntN(i + 1)
+		flat[i+1], flat[numEdges+i+1] = flat[j], flat[numEdges+j]
+	}
+	return tensors.FromFlatDataAndDimensions(flat, 2, numEdges)
+}
+
+func BenchmarkUnionEdges(b *testing.B) {
+	rng := rand.New(rand.NewPCG(42, 0))
+	inputs := []*tensors.Tensor{randomEdges(rng, 2_000_000, 1_000_000), randomEdges(rng, 2_000_000, 1_000_000)}
+	b.Run("Sequential", func(b *testing.B) {
+		for range b.N {
+			_, _ = UnionEdges(inputs...)
+		}
+	})
+	b.Run("Parallel", func(b *testing.B) {
+		for range b.N {
+			_, _ = UnionEdgesParallel(0, inputs...)
+		}
+	})
+}
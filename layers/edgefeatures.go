@@ -0,0 +1,212 @@
+package layers
+
+import (
+	"math"
+
+	"github.com/gomlx/exceptions"
+	. "github.com/gomlx/gomlx/graph"
+	"github.com/gomlx/gomlx/types/shapes"
+)
+
+// checkDistances panics if distances is not a float tensor.
+func checkDistances(name string, distances *Node) {
+	if !distances.DType().IsFloat() {
+		exceptions.Panicf("%s: invalid distances dtype %s, it must be float", name, distances.DType())
+	}
+}
+
+// basisIota returns an iota over the last axis, with the shape of distances with an extra axis of dimension
+// numBasis appended.
+func basisIota(distances *Node, numBasis int) *Node {
+	dims := append(distances.Shape().Clone().Dimensions, numBasis)
+	return Iota(distances.Graph(), shapes.Make(distances.DType(), dims...), -1)
+}
+
+// GaussianRBF expands the distances on a set of numBasis Gaussian radial basis functions, with centers
+// evenly spaced from start to stop (inclusive), as used in SchNet:
+//
+//	rbf[..., k] = exp(-gamma * (distances[...] - mu_k)^2), with gamma = 0.5 / (mu_{k+1} - mu_k)^2
+//
+// Args:
+//   - distances: any shape of some float dtype, typically [numEdges], the lengths of the edges.
+//   - numBasis: number of Gaussian functions, must be >= 2.
+//   - start, stop: the centers of the first and last Gaussian functions.
+//
+// It returns the expansion with the shape of distances with an extra axis of dimension numBasis appended.
+func GaussianRBF(distances *Node, numBasis int, start, stop float64) *Node {
+	checkDistances("GaussianRBF", distances)
+	if numBasis < 2 {
+		exceptions.Panicf("GaussianRBF: numBasis must be >= 2, got %d", numBasis)
+	}
+	if stop <= start {
+		exceptions.Panicf("GaussianRBF: stop (%g) must be larger than start (%g)", stop, start)
+	}
+	spacing := (stop - start) / float64(numBasis-1)
+	gamma := 0.5 / (spacing * spacing)
+	centers := AddScalar(MulScalar(basisIota(distances, numBasis), spacing), start)
+	diff := Sub(BroadcastToDims(distances, centers.Shape().Dimensions...), centers)
+	return Exp(MulScalar(Square(diff), -gamma))
+}
+
+// BesselRBF expands the distances on a set of numBasis spherical Bessel radial basis functions of order 0,
+// as used in DimeNet:
+//
+//	rbf[..., n-1] = sqrt(2/cutoff) * sin(n * pi * distances[...] / cutoff) / distances[...], for n in [1, numBasis]
+//
+// Zero distances take the limit value sqrt(2/cutoff) * n * pi / cutoff, so the result is always finite.
+// It is usually multiplied by a cutoff envelope, see CosineCutoff and PolynomialEnvelope.
+//
+// Args:
+//   - distances: any shape of some float dtype, typically [numEdges], the lengths of the edges.
+//   - numBasis: number of basis functions, must be >= 1.
+//   - cutoff: the radius of the neighborhood, typically the radius used with geometry.RadiusEdges.
+//
+// It returns the expansion with the shape of distances with an extra axis of dimension numBasis appended.
+func BesselRBF(distances *Node, numBasis int, cutoff float64) *Node {
+	checkDistances("BesselRBF", distances)
+	if numBasis < 1 {
+		exceptions.Panicf("BesselRBF: numBasis must be >= 1, got %d", numBasis)
+	}
+	if cutoff <= 0 {
+		exceptions.Panicf("BesselRBF: cutoff must be > 0, got %g", cutoff)
+	}
+	norm := math.Sqrt(2 / cutoff)
+
+	// frequencies[..., n-1] = n * pi / cutoff
+	frequencies := MulScalar(OnePlus(basisIota(distances, numBasis)), math.Pi/cutoff)
+	expanded := BroadcastToDims(distances, frequencies.Shape().Dimensions...)
+	isZero := Equal(expanded, ZerosLike(expanded))
+	// Use a safe denominator to avoid NaNs in the gradients of the branch not taken.
+	safeDistances := Where(isZero, OnesLike(expanded), expanded)
+	rbf := Div(Sin(Mul(frequencies, safeDistances)), safeDistances)
+	rbf = Where(isZero, frequencies, rbf)
+	return MulScalar(rbf, norm)
+}
+
+// CosineCutoff returns the smooth cosine cutoff envelope used in SchNet and others:
+//
+//	envelope = 0.5 * (cos(pi * distances / cutoff) + 1), if distances < cutoff, 0 otherwise.
+//
+// It returns a tensor with the same shape and dtype as distances.
+func CosineCutoff(distances *Node, cutoff float64) *Node {
+	checkDistances("CosineCutoff", distances)
+	if cutoff <= 0 {
+		exceptions.Panicf("CosineCutoff: cutoff must be > 0, got %g", cutoff)
+	}
+	g := distances.Graph()
+	envelope := MulScalar(OnePlus(Cos(MulScalar(distances, math.Pi/cutoff))), 0.5)
+	return Where(LessThan(distances, Scalar(g, distances.DType(), cutoff)), envelope, ZerosLike(distances))
+}
+
+// PolynomialEnvelope returns the smooth polynomial cutoff envelope used in DimeNet, with the given exponent p
+// (DimeNet uses p=5 by default). With d = distances / cutoff:
+//
+//	envelope = 1 - (p+1)(p+2)/2 * d^p + p(p+2) * d^(p+1) - p(p+1)/2 * d^(p+2), if d < 1, 0 otherwise.
+//
+// It returns a tensor with the same shape and dtype as distances.
+func PolynomialEnvelope(distances *Node, cutoff float64, exponent int) *Node {
+	checkDistances("PolynomialEnvelope", distances)
+	if cutoff <= 0 {
+		exceptions.Panicf("PolynomialEnvelope: cutoff must be > 0, got %g", cutoff)
+	}
+	if exponent < 1 {
+		exceptions.Panicf("PolynomialEnvelope: exponent must be >= 1, got %d", exponent)
+	}
+	g := distances.Graph()
+	p := float64(exponent)
+	d := DivScalar(distances, cutoff)
+	dP := PowScalar(d, p)
+	dP1 := Mul(dP, d)
+	dP2 := Mul(dP1, d)
+	envelope := OneMinus(MulScalar(dP, (p+1)*(p+2)/2))
+	envelope = Add(envelope, MulScalar(dP1, p*(p+2)))
+	envelope = Sub(envelope, MulScalar(dP2, p*(p+1)/2))
+	return Where(LessThan(d, ScalarOne(g, d.DType())), envelope, ZerosLike(distances))
+}
+
+// SphericalHarmonics returns the real spherical harmonics Y_l^m of the given vectors (typically the edges
+// displacement vectors, target minus source positions), for l in [0, lMax] and m in [-l, l].
+//
+// The harmonics are orthonormal on the sphere (the integral of Y_l^m squared over the unit sphere is 1), and
+// don't include the Condon-Shortley phase. So, for instance, Y_1^{-1, 0, 1} = sqrt(3/(4*pi)) * (y, z, x).
+//
+// Args:
+//   - vectors: shaped [..., 3] of some float dtype.
+//   - lMax: the maximum degree of the harmonics, must be >= 0.
+//   - normalize: if true, vectors are normalized to unit length first. If false, vectors must already be unit
+//     vectors, otherwise the results are not the spherical harmonics.
+//
+// It returns a tensor shaped [..., (lMax+1)^2], where the harmonics are ordered by l and then by m, that is:
+// Y_0^0, Y_1^{-1}, Y_1^0, Y_1^1, Y_2^{-2}, ... The index of Y_l^m is l*l + l + m.
+func SphericalHarmonics(vectors *Node, lMax int, normalize bool) *Node {
+	if !vectors.DType().IsFloat() {
+		exceptions.Panicf("SphericalHarmonics: invalid vectors dtype %s, it must be float", vectors.DType())
+	}
+	if vectors.Rank() < 1 || vectors.Shape().Dim(-1) != 3 {
+		exceptions.Panicf("SphericalHarmonics: vectors must be shaped [..., 3], got %s", vectors.Shape())
+	}
+	if lMax < 0 {
+		exceptions.Panicf("SphericalHarmonics: lMax must be >= 0, got %d", lMax)
+	}
+	if normalize {
+		vectors = L2Normalize(vectors, -1)
+	}
+	x := Squeeze(SliceAxis(vectors, -1, AxisElem(0)), -1)
+	y := Squeeze(SliceAxis(vectors, -1, AxisElem(1)), -1)
+	z := Squeeze(SliceAxis(vectors, -1, AxisElem(2)), -1)
+
+	// cosM[m] = r^m sin^m(theta) cos(m*phi) and sinM[m] = r^m sin^m(theta) sin(m*phi), as polynomials of x and y.
+	cosM := []*Node{OnesLike(x)}
+	sinM := []*Node{ZerosLike(x)}
+	for m := 1; m <= lMax; m++ {
+		cosM = append(cosM, Sub(Mul(x, cosM[m-1]), Mul(y, sinM[m-1])))
+		sinM = append(sinM, Add(Mul(x, sinM[m-1]), Mul(y, cosM[m-1])))
+	}
+
+	// legendre[l][m] = P_l^m(z) / sin^m(theta), the associated Legendre polynomials without the sin^m(theta)
+	// factor (which is included in cosM and sinM), calculated with the usual recurrences.
+	legendre := make([][]*Node, lMax+1)
+	for l := range legendre {
+		legendre[l] = make([]*Node, l+1)
+	}
+	doubleFactorial := 1.0 // (2m-1)!!
+	for m := 0; m <= lMax; m++ {
+		if m > 0 {
+			doubleFactorial *= float64(2*m - 1)
+		}
+		legendre[m][m] = MulScalar(OnesLike(z), doubleFactorial)
+		if m+1 <= lMax {
+			legendre[m+1][m] = Mul(MulScalar(z, float64(2*m+1)), legendre[m][m])
+		}
+		for l := m + 2; l <= lMax; l++ {
+			legendre[l][m] = DivScalar(
+				Sub(Mul(MulScalar(z, float64(2*l-1)), legendre[l-1][m]), MulScalar(legendre[l-2][m], float64(l+m-1))),
+				float64(l-m))
+		}
+	}
+
+	harmonics := make([]*Node, 0, (lMax+1)*(lMax+1))
+	for l := 0; l <= lMax; l++ {
+		for m := -l; m <= l; m++ {
+			absM := m
+			if absM < 0 {
+				absM = -absM
+			}
+			// norm = sqrt((2l+1)/(4pi) * (l-|m|)!/(l+|m|)!)
+			factorialRatio := 1.0
+			for k := l - absM + 1; k <= l+absM; k++ {
+				factorialRatio /= float64(k)
+			}
+			norm := math.Sqrt(float64(2*l+1) / (4 * math.Pi) * factorialRatio)
+			switch {
+			case m == 0:
+				harmonics = append(harmonics, MulScalar(legendre[l][0], norm))
+			case m > 0:
+				harmonics = append(harmonics, MulScalar(Mul(cosM[absM], legendre[l][absM]), math.Sqrt2*norm))
+			default:
+				harmonics = append(harmonics, MulScalar(Mul(sinM[absM], legendre[l][absM]), math.Sqrt2*norm))
+			}
+		}
+	}
+	return Stack(harmonics, -1)
+}
@@ -0,0 +1,93 @@
+package layers
+
+import (
+	"math"
+	"testing"
+
+	_ "github.com/gomlx/gomlx/backends/default"
+	. "github.com/gomlx/gomlx/graph"
+	"github.com/gomlx/gomlx/graph/graphtest"
+)
+
+func TestRadialBasis(t *testing.T) {
+	graphtest.RunTestGraphFn(t, "GaussianRBF", func(g *Graph) (inputs, outputs []*Node) {
+		distances := Const(g, []float32{0, 1.5})
+		inputs = []*Node{distances}
+		outputs = []*Node{GaussianRBF(distances, 3, 0, 2)}
+		return
+	}, []any{
+		[][]float32{
+			{1, float32(math.Exp(-0.5)), float32(math.Exp(-2))},
+			{float32(math.Exp(-0.5 * 2.25)), float32(math.Exp(-0.5 * 0.25)), float32(math.Exp(-0.5 * 0.25))},
+		},
+	}, 1e-4)
+
+	graphtest.RunTestGraphFn(t, "BesselRBF", func(g *Graph) (inputs, outputs []*Node) {
+		distances := Const(g, []float64{0, 0.5})
+		inputs = []*Node{distances}
+		outputs = []*Node{BesselRBF(distances, 2, 2)}
+		return
+	}, []any{
+		[][]float64{
+			{math.Pi / 2, math.Pi},
+			{math.Sin(math.Pi/4) / 0.5, 1 / 0.5},
+		},
+	}, 1e-4)
+
+	graphtest.RunTestGraphFn(t, "CosineCutoff", func(g *Graph) (inputs, outputs []*Node) {
+		distances := Const(g, []float32{0, 1, 2, 3})
+		inputs = []*Node{distances}
+		outputs = []*Node{CosineCutoff(distances, 2)}
+		return
+	}, []any{
+		[]float32{1, 0.5, 0, 0},
+	}, 1e-4)
+
+	graphtest.RunTestGraphFn(t, "PolynomialEnvelope", func(g *Graph) (inputs, outputs []*Node) {
+		distances := Const(g, []float32{0, 1, 2, 3})
+		inputs = []*Node{distances}
+		outputs = []*Node{PolynomialEnvelope(distances, 2, 5)}
+		return
+	}, []any{
+		// For d=0.5: 1 - 21*0.5^5 + 35*0.5^6 - 15*0.5^7
+		[]float32{1, float32(1 - 21.0/32 + 35.0/64 - 15.0/128), 0, 0},
+	}, 1e-4)
+}
+
+func TestSphericalHarmonics(t *testing.T) {
+	x, y, z := 2.0/7, 3.0/7, 6.0/7 // Unit vector.
+	c1 := math.Sqrt(3 / (4 * math.Pi))
+	c2 := 0.5 * math.Sqrt(15/math.Pi)
+	graphtest.RunTestGraphFn(t, "SphericalHarmonics: lMax=2", func(g *Graph) (inputs, outputs []*Node) {
+		vectors := Const(g, [][]float64{{2 * x, 2 * y, 2 * z}})
+		inputs = []*Node{vectors}
+		outputs = []*Node{SphericalHarmonics(vectors, 2, true)}
+		return
+	}, []any{
+		[][]float64{{
+			0.5 / math.Sqrt(math.Pi),
+			c1 * y, c1 * z, c1 * x,
+			c2 * x * y, c2 * y * z, 0.25 * math.Sqrt(5/math.Pi) * (3*z*z - 1), c2 * x * z, 0.5 * c2 * (x*x - y*y),
+		}},
+	}, 1e-6)
+
+	// Addition theorem: \sum_m Y_l^m(v)^2 = (2l+1)/(4pi) for any unit vector v.
+	const lMax = 5
+	graphtest.RunTestGraphFn(t, "SphericalHarmonics: addition theorem", func(g *Graph) (inputs, outputs []*Node) {
+		vectors := Const(g, [][]float64{{1, -2, 0.5}, {0, 0, -1}})
+		inputs = []*Node{vectors}
+		harmonics := Square(SphericalHarmonics(vectors, lMax, true))
+		sums := make([]*Node, 0, lMax+1)
+		for l := 0; l <= lMax; l++ {
+			sums = append(sums, ReduceSum(SliceAxis(harmonics, -1, AxisRange(l*l, (l+1)*(l+1))), -1))
+		}
+		outputs = []*Node{Stack(sums, -1)}
+		return
+	}, func() []any {
+		want := make([]float64, lMax+1)
+		for l := range want {
+			want[l] = float64(2*l+1) / (4 * math.Pi)
+		}
+		return []any{[][]float64{want, want}}
+	}(), 1e-6)
+}